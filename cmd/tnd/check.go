@@ -0,0 +1,132 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// Exit codes of the check command.
+const (
+	exitTrusted    = 0
+	exitUntrusted  = 1
+	exitCheckError = 2
+)
+
+// stdout is the output of the commands, for testing.
+var stdout io.Writer = os.Stdout
+
+// checkFlags are the command line flags of the check command.
+type checkFlags struct {
+	commonFlags
+	timeout time.Duration
+}
+
+// parseCheckFlags parses the command line arguments args of the check
+// command.
+func parseCheckFlags(args []string) (*checkFlags, error) {
+	f := &checkFlags{}
+	flags := flag.NewFlagSet("tnd check", flag.ContinueOnError)
+	f.define(flags, "warn")
+	flags.DurationVar(&f.timeout, "timeout", time.Minute,
+		"overall timeout of the check, 0 means no timeout")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := f.parse(flags); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// printResult prints the result r with the details of all checked servers
+// to w.
+func printResult(w io.Writer, r *tnd.Result) {
+	if r.Trusted {
+		_, _ = fmt.Fprintf(w, "trusted network, server %s\n", r.Server)
+	} else {
+		_, _ = fmt.Fprintln(w, "untrusted network")
+	}
+
+	for _, s := range r.Servers {
+		state := "trusted"
+		if !s.Trusted {
+			state = "untrusted (" + s.Reason.String() + ")"
+		}
+		_, _ = fmt.Fprintf(w, "  %s: %s, latency %v", s.URL, state,
+			s.Latency.Round(time.Millisecond))
+		if s.Fingerprint != "" {
+			_, _ = fmt.Fprintf(w, ", fingerprint %s", s.Fingerprint)
+		}
+		if s.Error != nil {
+			_, _ = fmt.Fprintf(w, ", error: %v", s.Error)
+		}
+		_, _ = fmt.Fprintln(w)
+	}
+}
+
+// runCheck runs the check command with the command line arguments args and
+// returns the exit code.
+func runCheck(args []string) int {
+	flags, err := parseCheckFlags(args)
+	if errors.Is(err, flag.ErrHelp) {
+		// do not report a trusted network to scripts
+		return exitCheckError
+	}
+	if err != nil {
+		log.WithError(err).Error("TND invalid command line")
+		return exitCheckError
+	}
+	log.SetLevel(flags.logLevel)
+
+	// create detector, there is no network change to settle, so do not
+	// wait before server checks
+	config, err := flags.loadConfig()
+	if err != nil {
+		log.WithError(err).Error("TND invalid config")
+		return exitCheckError
+	}
+	config.Config.WaitCheck = 0
+	t := newDetector(config.Config)
+	t.SetDialer(config.Dialer)
+	if err := t.SetTrustedServers(config.Servers); err != nil {
+		log.WithError(err).Error("TND invalid config")
+		return exitCheckError
+	}
+
+	// run check until done, interrupted or timed out
+	ctx, cancel := signal.NotifyContext(context.Background(),
+		syscall.SIGINT, syscall.SIGTERM)
+	defer cancel()
+	if flags.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, flags.timeout)
+		defer cancel()
+	}
+	r, err := t.Check(ctx)
+	if err != nil {
+		log.WithError(err).Error("TND check aborted")
+		return exitCheckError
+	}
+
+	if flags.output == "json" {
+		if err := printJSON(stdout, r); err != nil {
+			log.WithError(err).Error("TND could not print result")
+			return exitCheckError
+		}
+	} else {
+		printResult(stdout, r)
+	}
+	if r.Trusted {
+		return exitTrusted
+	}
+	return exitUntrusted
+}
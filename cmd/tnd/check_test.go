@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// TestParseCheckFlags tests parseCheckFlags.
+func TestParseCheckFlags(t *testing.T) {
+	// test defaults
+	f, err := parseCheckFlags([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.timeout != time.Minute || f.logLevel != log.WarnLevel {
+		t.Errorf("invalid default flags: %v", f)
+	}
+
+	// test all flags
+	f, err = parseCheckFlags([]string{
+		"-config", "/test/tnd.json",
+		"-httpsservers", "https://test.example.com:hash",
+		"-loglevel", "debug",
+		"-timeout", "5s",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.configFile != "/test/tnd.json" ||
+		f.httpsServers != "https://test.example.com:hash" ||
+		f.logLevel != log.DebugLevel || f.timeout != 5*time.Second {
+		t.Errorf("invalid flags: %v", f)
+	}
+
+	// test help and invalid
+	if _, err := parseCheckFlags([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("got %v, want help error", err)
+	}
+	if _, err := parseCheckFlags([]string{"-timeout", "invalid"}); err == nil {
+		t.Error("flags should be invalid")
+	}
+}
+
+// TestPrintResult tests printResult.
+func TestPrintResult(t *testing.T) {
+	// test trusted
+	b := &bytes.Buffer{}
+	printResult(b, &tnd.Result{
+		Trusted: true,
+		Server:  "https://test1.example.com",
+		Servers: []*tnd.ServerResult{
+			{
+				URL:     "https://test2.example.com",
+				Reason:  tnd.ReasonHashMismatch,
+				Error:   tnd.ErrHashMismatch,
+				Latency: 12 * time.Millisecond,
+			},
+			{
+				URL:         "https://test1.example.com",
+				Trusted:     true,
+				Latency:     10 * time.Millisecond,
+				Fingerprint: "sha256:abc",
+			},
+		},
+	})
+	want := "trusted network, server https://test1.example.com\n" +
+		"  https://test2.example.com: untrusted (hash mismatch), latency 12ms, error: " +
+		tnd.ErrHashMismatch.Error() + "\n" +
+		"  https://test1.example.com: trusted, latency 10ms, fingerprint sha256:abc\n"
+	if b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+
+	// test untrusted
+	b.Reset()
+	printResult(b, &tnd.Result{})
+	if b.String() != "untrusted network\n" {
+		t.Errorf("got %q, want untrusted network", b.String())
+	}
+}
+
+// TestRunCheck tests runCheck.
+func TestRunCheck(t *testing.T) {
+	defer func(w io.Writer) { stdout = w }(stdout)
+	b := &bytes.Buffer{}
+	stdout = b
+
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	sha := sha256.Sum256(ts.Certificate().Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	for _, test := range []struct {
+		args   []string
+		want   int
+		output string
+	}{
+		{[]string{"-httpsservers", ts.URL + ":" + hash}, exitTrusted, "trusted network"},
+		{[]string{"-httpsservers", ts.URL + ":" + testHash("invalid")}, exitUntrusted, "untrusted network"},
+		{[]string{"-httpsservers", ts.URL + ":" + hash, "-output", "json"}, exitTrusted, `{"trusted":true,"server":"` + ts.URL + `"`},
+		{[]string{"-httpsservers", ts.URL + ":" + hash, "-timeout", "1ns"}, exitCheckError, ""},
+		{[]string{"-httpsservers", ts.URL + ":invalid"}, exitCheckError, ""},
+		{[]string{}, exitCheckError, ""},
+		{[]string{"-unknown"}, exitCheckError, ""},
+		{[]string{"-h"}, exitCheckError, ""},
+	} {
+		b.Reset()
+		got := run(append([]string{"check"}, test.args...))
+		if got != test.want {
+			t.Errorf("%v: got %d, want %d", test.args, got, test.want)
+		}
+		if !strings.HasPrefix(b.String(), test.output) ||
+			(test.output == "" && b.Len() != 0) {
+			t.Errorf("%v: got output %q, want %q", test.args, b.String(), test.output)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"os/user"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/api"
+	"github.com/telekom-mms/tnd/internal/dbusapi"
+	"github.com/telekom-mms/tnd/internal/files"
+	"github.com/telekom-mms/tnd/internal/logging"
+	"github.com/telekom-mms/tnd/internal/sdnotify"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+	"github.com/telekom-mms/tnd/pkg/tnd/tndmetrics"
+)
+
+// daemonFlags are the command line flags of the daemon.
+type daemonFlags struct {
+	commonFlags
+	watchConfig bool
+	dbus        string
+	socket      string
+	socketMode  fs.FileMode
+	socketGroup int
+	metrics     string
+}
+
+// parseSocketMode parses the octal file mode s of the API socket.
+func parseSocketMode(s string) (fs.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("invalid socket mode: %s", s)
+	}
+	return fs.FileMode(mode), nil
+}
+
+// parseSocketGroup parses the group name or id s of the API socket.
+func parseSocketGroup(s string) (int, error) {
+	if gid, err := strconv.Atoi(s); err == nil && gid >= 0 {
+		return gid, nil
+	}
+	g, err := user.LookupGroup(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid socket group: %w", err)
+	}
+	return strconv.Atoi(g.Gid)
+}
+
+// parseDaemonFlags parses the command line arguments args of the daemon.
+func parseDaemonFlags(args []string) (*daemonFlags, error) {
+	f := &daemonFlags{
+		socketMode:  0660,
+		socketGroup: -1,
+	}
+	flags := flag.NewFlagSet("tnd", flag.ContinueOnError)
+	f.define(flags, "info")
+	flags.BoolVar(&f.watchConfig, "watchconfig", false,
+		"reload config file on changes")
+	flags.StringVar(&f.dbus, "dbus", "",
+		"provide D-Bus service on bus: session, system")
+	flags.StringVar(&f.socket, "socket", "",
+		"provide JSON API on unix socket `path`")
+	flags.Func("socketmode", "file `mode` of API socket (default 0660)",
+		func(s string) (err error) {
+			f.socketMode, err = parseSocketMode(s)
+			return
+		})
+	flags.Func("socketgroup", "`group` of API socket",
+		func(s string) (err error) {
+			f.socketGroup, err = parseSocketGroup(s)
+			return
+		})
+	flags.StringVar(&f.metrics, "metrics", "",
+		"serve Prometheus metrics on `address`, e.g., localhost:9310")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if err := f.parse(flags); err != nil {
+		return nil, err
+	}
+	switch f.dbus {
+	case "", "session", "system":
+	default:
+		return nil, fmt.Errorf("invalid D-Bus bus: %s", f.dbus)
+	}
+	return f, nil
+}
+
+// notify sends state to systemd.
+func notify(state string) {
+	if _, err := sdnotify.Notify(state); err != nil {
+		log.WithError(err).Error("TND could not notify systemd")
+	}
+}
+
+// daemon is the TND daemon.
+type daemon struct {
+	flags   *daemonFlags
+	config  *tnd.FileConfig
+	tnd     tnd.TND
+	dbus    *dbusapi.Service
+	api     *api.Server
+	metrics *http.Server
+	signals chan os.Signal
+
+	// config file watch and its probe channel
+	cw           files.Watcher
+	configProbes chan struct{}
+}
+
+// startMetrics starts serving the metrics.
+func (d *daemon) startMetrics() error {
+	l, err := net.Listen("tcp", d.metrics.Addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		if err := d.metrics.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("TND metrics server stopped")
+		}
+	}()
+	return nil
+}
+
+// applyConfig applies the reloaded configuration config.
+func (d *daemon) applyConfig(config *tnd.FileConfig) {
+	notify(sdnotify.Reloading())
+	defer notify(sdnotify.Ready)
+
+	if err := d.tnd.SetFileConfig(config); err != nil {
+		log.WithError(err).Error("TND could not apply config")
+		return
+	}
+	d.config = config
+	log.Info("TND reloaded config")
+}
+
+// reload reloads the configuration, e.g., on SIGHUP.
+func (d *daemon) reload() {
+	config, err := d.flags.loadConfig()
+	if err != nil {
+		log.WithError(err).Error("TND could not reload config")
+		return
+	}
+	d.applyConfig(config)
+}
+
+// reloadConfigFile reloads the configuration after a change of the config
+// file like reload, but ignores unchanged configurations.
+func (d *daemon) reloadConfigFile() {
+	config, err := d.flags.loadConfig()
+	if err != nil {
+		log.WithError(err).Error("TND could not reload config")
+		return
+	}
+	if reflect.DeepEqual(config, d.config) {
+		log.Debug("TND config unchanged")
+		return
+	}
+	d.applyConfig(config)
+}
+
+// run runs the daemon until it is stopped by a signal and returns the exit
+// code.
+func (d *daemon) run() int {
+	if err := d.tnd.Start(); err != nil {
+		log.WithError(err).Error("TND could not start")
+		return exitError
+	}
+	if d.dbus != nil {
+		if err := d.dbus.Start(); err != nil {
+			log.WithError(err).Error("TND could not start D-Bus service")
+			d.tnd.Stop()
+			return exitError
+		}
+		defer d.dbus.Stop()
+	}
+	if d.api != nil {
+		if err := d.api.Start(); err != nil {
+			log.WithError(err).Error("TND could not start API")
+			d.tnd.Stop()
+			return exitError
+		}
+		defer d.api.Stop()
+	}
+	if d.metrics != nil {
+		if err := d.startMetrics(); err != nil {
+			log.WithError(err).Error("TND could not start metrics server")
+			d.tnd.Stop()
+			return exitError
+		}
+		defer func() { _ = d.metrics.Close() }()
+	}
+	if d.cw != nil {
+		if err := d.cw.Start(); err != nil {
+			log.WithError(err).Error("TND could not watch config file")
+			d.tnd.Stop()
+			return exitError
+		}
+		defer d.cw.Stop()
+	}
+	notify(sdnotify.Ready)
+	log.Info("TND started")
+
+	results := d.tnd.DetailedResults()
+	errs := d.tnd.Errors()
+	state := ""
+	for {
+		select {
+		case r, ok := <-results:
+			if !ok {
+				log.Error("TND stopped unexpectedly")
+				return exitError
+			}
+			log.WithFields(log.Fields{
+				"trusted": r.Trusted,
+				"server":  r.Server,
+				"trigger": r.Trigger,
+			}).Debug("TND result")
+			if d.dbus != nil {
+				d.dbus.Update(r)
+			}
+			if d.api != nil {
+				d.api.Update(r)
+			}
+			if d.flags.output == "json" {
+				if err := printJSON(stdout, r); err != nil {
+					log.WithError(err).Error("TND could not print result")
+				}
+			}
+
+			// log state changes
+			s := "untrusted network"
+			if r.Trusted {
+				s = "trusted network"
+			}
+			if s != state {
+				state = s
+				log.WithField("server", r.Server).Info("TND detected " + s)
+				notify(sdnotify.Status(s))
+			}
+
+		case err, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			log.WithError(err).Error("TND error")
+
+		case <-d.configProbes:
+			d.reloadConfigFile()
+
+		case sig := <-d.signals:
+			if sig == syscall.SIGHUP {
+				d.reload()
+				continue
+			}
+			log.WithField("signal", sig).Info("TND stopping")
+			notify(sdnotify.Stopping)
+			d.tnd.Stop()
+			return exitOK
+		}
+	}
+}
+
+// newDetector returns a new Detector for config, for testing.
+var newDetector = func(config *tnd.Config) tnd.TND {
+	return tnd.NewDetector(config)
+}
+
+// connectBus returns a new connection to the D-Bus bus, for testing.
+var connectBus = func(bus string) (*dbus.Conn, error) {
+	if bus == "system" {
+		return dbus.ConnectSystemBus()
+	}
+	return dbus.ConnectSessionBus()
+}
+
+// newDaemon returns a new daemon with the command line flags and config.
+func newDaemon(flags *daemonFlags, config *tnd.FileConfig) (*daemon, error) {
+	t := newDetector(config.Config)
+	t.SetDialer(config.Dialer)
+	if err := t.SetTrustedServers(config.Servers); err != nil {
+		return nil, err
+	}
+	d := &daemon{
+		flags:   flags,
+		config:  config,
+		tnd:     t,
+		signals: make(chan os.Signal, 1),
+	}
+	if flags.watchConfig && flags.configFile != "" {
+		// reload with the daemon's loader like on SIGHUP, so the
+		// command line flags still apply and servers are required
+		path := flags.configFile
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		d.configProbes = make(chan struct{})
+		d.cw = files.NewWatch(d.configProbes, []string{path},
+			logging.Default())
+	}
+	if flags.dbus != "" {
+		conn, err := connectBus(flags.dbus)
+		if err != nil {
+			return nil, fmt.Errorf("could not connect to D-Bus: %w", err)
+		}
+		d.dbus = dbusapi.NewService(conn, t)
+	}
+	if flags.socket != "" {
+		d.api = api.NewServer(flags.socket, flags.socketMode,
+			flags.socketGroup, t)
+	}
+	if flags.metrics != "" {
+		c := tndmetrics.NewCollector()
+		t.SetMetrics(c)
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", c)
+		d.metrics = &http.Server{
+			Addr:              flags.metrics,
+			Handler:           mux,
+			ReadHeaderTimeout: 10 * time.Second,
+		}
+	}
+	return d, nil
+}
+
+// runDaemon runs the daemon with the command line arguments args and
+// returns the exit code.
+func runDaemon(args []string) int {
+	flags, err := parseDaemonFlags(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		log.WithError(err).Error("TND invalid command line")
+		return exitUsage
+	}
+	log.SetLevel(flags.logLevel)
+
+	config, err := flags.loadConfig()
+	if err != nil {
+		log.WithError(err).Error("TND invalid config")
+		return exitError
+	}
+	d, err := newDaemon(flags, config)
+	if err != nil {
+		log.WithError(err).Error("TND could not create daemon")
+		return exitError
+	}
+
+	signal.Notify(d.signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	defer signal.Stop(d.signals)
+	return d.run()
+}
@@ -0,0 +1,569 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/dbusapi"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+	"github.com/telekom-mms/tnd/pkg/tnd/tndtest"
+)
+
+// TestParseDaemonFlags tests parseDaemonFlags.
+func TestParseDaemonFlags(t *testing.T) {
+	// test defaults
+	f, err := parseDaemonFlags([]string{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.configFile != "" || f.watchConfig || f.dbus != "" ||
+		f.httpsServers != "" || f.logLevel != log.InfoLevel ||
+		f.output != "text" || f.socket != "" || f.socketMode != 0660 ||
+		f.socketGroup != -1 || f.metrics != "" {
+		t.Errorf("invalid default flags: %v", f)
+	}
+
+	// test all flags
+	f, err = parseDaemonFlags([]string{
+		"-config", "/test/tnd.json",
+		"-watchconfig",
+		"-httpsservers", "https://test.example.com:hash",
+		"-loglevel", "debug",
+		"-output", "json",
+		"-dbus", "session",
+		"-socket", "/test/tnd.sock",
+		"-socketmode", "0600",
+		"-socketgroup", "0",
+		"-metrics", "localhost:9310",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.output != "json" || f.dbus != "session" {
+		t.Errorf("invalid output or dbus: %s, %s", f.output, f.dbus)
+	}
+	if f.socket != "/test/tnd.sock" || f.socketMode != 0600 || f.socketGroup != 0 ||
+		f.metrics != "localhost:9310" {
+		t.Errorf("invalid socket flags: %v", f)
+	}
+	if f.configFile != "/test/tnd.json" || !f.watchConfig ||
+		f.httpsServers != "https://test.example.com:hash" ||
+		f.logLevel != log.DebugLevel {
+		t.Errorf("invalid flags: %v", f)
+	}
+
+	// test help
+	if _, err := parseDaemonFlags([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("got %v, want help error", err)
+	}
+
+	// test invalid
+	for _, invalid := range [][]string{
+		{"-loglevel", "invalid"},
+		{"-output", "xml"},
+		{"-dbus", "other"},
+		{"-socketmode", "999"},
+		{"-socketmode", "01777"},
+		{"-socketgroup", "does-not-exist"},
+		{"-unknown"},
+		{"unexpected"},
+	} {
+		if _, err := parseDaemonFlags(invalid); err == nil {
+			t.Errorf("%v: flags should be invalid", invalid)
+		}
+	}
+}
+
+// testNotifySocket sets a systemd notify socket and returns its messages.
+func testNotifySocket(t *testing.T) chan string {
+	name := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: name, Net: "unixgram"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", name)
+
+	messages := make(chan string, 16)
+	go func() {
+		b := make([]byte, 256)
+		for {
+			n, err := conn.Read(b)
+			if err != nil {
+				return
+			}
+			messages <- string(b[:n])
+		}
+	}()
+	return messages
+}
+
+// TestDaemonRun tests run of daemon.
+func TestDaemonRun(t *testing.T) {
+	defer func(w io.Writer) { stdout = w }(stdout)
+	b := &bytes.Buffer{}
+	stdout = b
+
+	messages := testNotifySocket(t)
+	wantMessage := func(want string) {
+		t.Helper()
+		select {
+		case got := <-messages:
+			// ignore MONOTONIC_USEC of RELOADING
+			got, _, _ = strings.Cut(got, "\n")
+			if got != want {
+				t.Errorf("got %s, want %s", got, want)
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("missing message %s", want)
+		}
+	}
+
+	// create daemon with test detector
+	results := make(chan *tnd.Result)
+	errs := make(chan error)
+	reloaded := make(chan *tnd.FileConfig, 1)
+	stopped := false
+	d := &daemon{
+		flags: &daemonFlags{commonFlags: commonFlags{
+			httpsServers: "https://test.example.com:" + testHash("hash1"),
+			output:       "json",
+		}},
+		tnd: &tndtest.Detector{Funcs: tndtest.Funcs{
+			DetailedResults: func() chan *tnd.Result { return results },
+			Errors:          func() chan error { return errs },
+			SetFileConfig: func(f *tnd.FileConfig) error {
+				reloaded <- f
+				return nil
+			},
+			Stop: func() { stopped = true },
+		}},
+		signals: make(chan os.Signal, 1),
+	}
+	exit := make(chan int)
+	go func() { exit <- d.run() }()
+	wantMessage("READY=1")
+
+	// test results and errors
+	results <- &tnd.Result{Trusted: true, Server: "https://test.example.com"}
+	wantMessage("STATUS=trusted network")
+	results <- &tnd.Result{Trusted: true}
+	results <- &tnd.Result{Trusted: false}
+	wantMessage("STATUS=untrusted network")
+	errs <- errors.New("test error")
+
+	// test reload
+	d.signals <- syscall.SIGHUP
+	wantMessage("RELOADING=1")
+	if f := <-reloaded; len(f.Servers) != 1 {
+		t.Errorf("invalid reloaded config: %v", f)
+	}
+	wantMessage("READY=1")
+
+	// test stop
+	d.signals <- syscall.SIGTERM
+	wantMessage("STOPPING=1")
+	if got := <-exit; got != exitOK {
+		t.Errorf("got %d, want %d", got, exitOK)
+	}
+	if !stopped {
+		t.Error("detector should be stopped")
+	}
+
+	// check json output, one line per result
+	lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d lines, want 3: %q", len(lines), b.String())
+	}
+	r := &struct {
+		Trusted bool
+		Server  string
+	}{}
+	if err := json.Unmarshal([]byte(lines[0]), r); err != nil ||
+		!r.Trusted || r.Server != "https://test.example.com" {
+		t.Errorf("invalid json output %s: %v", lines[0], err)
+	}
+}
+
+// TestDaemonRunErrors tests run of daemon with errors.
+func TestDaemonRunErrors(t *testing.T) {
+	// test start error
+	d := &daemon{
+		tnd: &tndtest.Detector{Funcs: tndtest.Funcs{
+			Start: func() error { return errors.New("test error") },
+		}},
+	}
+	if got := d.run(); got != exitError {
+		t.Errorf("got %d, want %d", got, exitError)
+	}
+
+	// test closed results
+	results := make(chan *tnd.Result)
+	close(results)
+	d = &daemon{
+		tnd: &tndtest.Detector{Funcs: tndtest.Funcs{
+			DetailedResults: func() chan *tnd.Result { return results },
+		}},
+	}
+	if got := d.run(); got != exitError {
+		t.Errorf("got %d, want %d", got, exitError)
+	}
+}
+
+// TestNewDaemon tests newDaemon.
+func TestNewDaemon(t *testing.T) {
+	defer func(f func(*tnd.Config) tnd.TND) { newDetector = f }(newDetector)
+
+	var servers []*tnd.Server
+	newDetector = func(*tnd.Config) tnd.TND {
+		return &tndtest.Detector{Funcs: tndtest.Funcs{
+			SetTrustedServers: func(s []*tnd.Server) error {
+				servers = s
+				return nil
+			},
+		}}
+	}
+
+	config := tnd.NewFileConfig()
+	config.Servers = []*tnd.Server{{URL: "https://test.example.com"}}
+	d, err := newDaemon(&daemonFlags{
+		commonFlags: commonFlags{configFile: "/test/tnd.json"},
+		watchConfig: true,
+	}, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.tnd == nil || d.signals == nil || d.config != config {
+		t.Error("invalid daemon")
+	}
+	if d.cw == nil || d.configProbes == nil {
+		t.Error("config file should be watched")
+	}
+	if len(servers) != 1 {
+		t.Error("detector not configured")
+	}
+}
+
+// TestDaemonReloadConfigFile tests reloadConfigFile of daemon.
+func TestDaemonReloadConfigFile(t *testing.T) {
+	var reloaded []*tnd.FileConfig
+	d := &daemon{
+		flags: &daemonFlags{commonFlags: commonFlags{
+			httpsServers: "https://override.example.com:" + testHash("hash2"),
+		}},
+		tnd: &tndtest.Detector{Funcs: tndtest.Funcs{
+			SetFileConfig: func(f *tnd.FileConfig) error {
+				reloaded = append(reloaded, f)
+				return nil
+			},
+		}},
+	}
+
+	// test changed config file, servers of flags override file
+	d.flags.configFile = writeConfigFile(t, `{
+		"trusted_timer": "1h",
+		"servers": [{"url": "https://test.example.com", "hashes": ["`+testHash("hash1")+`"]}]
+	}`)
+	d.reloadConfigFile()
+	if len(reloaded) != 1 || reloaded[0].Config.TrustedTimer != time.Hour ||
+		len(reloaded[0].Servers) != 1 ||
+		reloaded[0].Servers[0].URL != "https://override.example.com" {
+		t.Fatalf("invalid reloaded config: %v", reloaded)
+	}
+
+	// test unchanged config file
+	d.reloadConfigFile()
+	if len(reloaded) != 1 {
+		t.Error("unchanged config should not be applied")
+	}
+
+	// test config file without servers
+	d.flags.httpsServers = ""
+	d.flags.configFile = writeConfigFile(t, `{"trusted_timer": "2h"}`)
+	d.reloadConfigFile()
+	if len(reloaded) != 1 {
+		t.Error("config without servers should not be applied")
+	}
+}
+
+// TestDaemonRunWatchConfig tests run of daemon with config file watching.
+func TestDaemonRunWatchConfig(t *testing.T) {
+	defer func(f func(*tnd.Config) tnd.TND) { newDetector = f }(newDetector)
+
+	reloaded := make(chan *tnd.FileConfig, 1)
+	newDetector = func(*tnd.Config) tnd.TND {
+		return &tndtest.Detector{Funcs: tndtest.Funcs{
+			SetFileConfig: func(f *tnd.FileConfig) error {
+				reloaded <- f
+				return nil
+			},
+		}}
+	}
+	path := writeConfigFile(t, `{
+		"servers": [{"url": "https://test.example.com", "hashes": ["`+testHash("hash1")+`"]}]
+	}`)
+	flags := &daemonFlags{
+		commonFlags: commonFlags{
+			configFile:   path,
+			httpsServers: "https://override.example.com:" + testHash("hash2"),
+		},
+		watchConfig: true,
+	}
+	config, err := flags.loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := newDaemon(flags, config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit := make(chan int)
+	go func() { exit <- d.run() }()
+
+	// change config file, servers of flags should be kept
+	if err := os.WriteFile(path, []byte(`{
+		"trusted_timer": "1h",
+		"servers": [{"url": "https://test.example.com", "hashes": ["`+testHash("hash1")+`"]}]
+	}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case f := <-reloaded:
+		if f.Config.TrustedTimer != time.Hour ||
+			f.Servers[0].URL != "https://override.example.com" {
+			t.Errorf("invalid reloaded config: %v", f)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("config file should be reloaded")
+	}
+
+	// test stop
+	d.signals <- syscall.SIGTERM
+	if got := <-exit; got != exitOK {
+		t.Errorf("got %d, want %d", got, exitOK)
+	}
+}
+
+// testBus starts a private session bus and sets its address as session
+// bus address.
+func testBus(t *testing.T) {
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address=1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", strings.TrimSpace(address))
+}
+
+// TestDaemonRunDBus tests run of daemon with D-Bus service.
+func TestDaemonRunDBus(t *testing.T) {
+	defer func(f func(*tnd.Config) tnd.TND) { newDetector = f }(newDetector)
+	testBus(t)
+
+	results := make(chan *tnd.Result)
+	newDetector = func(*tnd.Config) tnd.TND {
+		return &tndtest.Detector{Funcs: tndtest.Funcs{
+			DetailedResults: func() chan *tnd.Result { return results },
+		}}
+	}
+	d, err := newDaemon(&daemonFlags{dbus: "session"}, tnd.NewFileConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit := make(chan int)
+	go func() { exit <- d.run() }()
+
+	// send result and wait until it is handled
+	results <- &tnd.Result{Trusted: true, Server: "https://test.example.com"}
+	results <- &tnd.Result{Trusted: true, Server: "https://test.example.com"}
+
+	// check trusted property
+	conn, err := dbus.ConnectSessionBus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = conn.Close() }()
+	v, err := conn.Object(dbusapi.Name, dbusapi.Path).GetProperty(
+		dbusapi.Interface + "." + dbusapi.PropertyTrusted)
+	if err != nil || v.Value() != true {
+		t.Errorf("invalid trusted property: %v, %v", v, err)
+	}
+
+	// test stop
+	d.signals <- syscall.SIGTERM
+	if got := <-exit; got != exitOK {
+		t.Errorf("got %d, want %d", got, exitOK)
+	}
+
+	// test connection error
+	t.Setenv("DBUS_SESSION_BUS_ADDRESS", "unix:path=/does/not/exist")
+	if _, err := newDaemon(&daemonFlags{dbus: "session"}, tnd.NewFileConfig()); err == nil {
+		t.Error("invalid bus should return error")
+	}
+}
+
+// TestDaemonRunAPI tests run of daemon with API socket.
+func TestDaemonRunAPI(t *testing.T) {
+	defer func(f func(*tnd.Config) tnd.TND) { newDetector = f }(newDetector)
+
+	results := make(chan *tnd.Result)
+	newDetector = func(*tnd.Config) tnd.TND {
+		return &tndtest.Detector{Funcs: tndtest.Funcs{
+			DetailedResults: func() chan *tnd.Result { return results },
+		}}
+	}
+	socket := filepath.Join(t.TempDir(), "tnd.sock")
+	d, err := newDaemon(&daemonFlags{
+		socket:      socket,
+		socketMode:  0600,
+		socketGroup: -1,
+	}, tnd.NewFileConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	exit := make(chan int)
+	go func() { exit <- d.run() }()
+
+	// send result and wait until it is handled
+	results <- &tnd.Result{Trusted: true, Server: "https://test.example.com"}
+	results <- &tnd.Result{Trusted: true, Server: "https://test.example.com"}
+
+	// check state
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socket)
+		},
+	}}
+	resp, err := client.Get("http://tnd/state")
+	if err != nil {
+		t.Fatal(err)
+	}
+	state := &struct{ Trusted bool }{}
+	err = json.NewDecoder(resp.Body).Decode(state)
+	_ = resp.Body.Close()
+	if err != nil || !state.Trusted {
+		t.Errorf("invalid state: %v, %v", state, err)
+	}
+
+	// test stop
+	d.signals <- syscall.SIGTERM
+	if got := <-exit; got != exitOK {
+		t.Errorf("got %d, want %d", got, exitOK)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket should be removed: %v", err)
+	}
+
+	// test start error
+	d, err = newDaemon(&daemonFlags{
+		socket: filepath.Join(t.TempDir(), "does/not/exist"),
+	}, tnd.NewFileConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.run(); got != exitError {
+		t.Errorf("got %d, want %d", got, exitError)
+	}
+}
+
+// TestDaemonRunMetrics tests run of daemon with metrics.
+func TestDaemonRunMetrics(t *testing.T) {
+	defer func(f func(*tnd.Config) tnd.TND) { newDetector = f }(newDetector)
+
+	results := make(chan *tnd.Result)
+	var metrics tnd.Metrics
+	newDetector = func(*tnd.Config) tnd.TND {
+		return &tndtest.Detector{Funcs: tndtest.Funcs{
+			DetailedResults: func() chan *tnd.Result { return results },
+			SetMetrics:      func(m tnd.Metrics) { metrics = m },
+		}}
+	}
+	d, err := newDaemon(&daemonFlags{metrics: "127.0.0.1:0"}, tnd.NewFileConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if metrics == nil {
+		t.Fatal("metrics not set")
+	}
+
+	// use listener with random port
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.metrics.Addr = l.Addr().String()
+	_ = l.Close()
+
+	exit := make(chan int)
+	go func() { exit <- d.run() }()
+
+	// send results and wait until they are handled
+	metrics.ProbeStarted(tnd.TriggerRoute)
+	metrics.ProbeFinished(&tnd.Result{Trusted: true})
+	results <- &tnd.Result{Trusted: true}
+
+	// check metrics
+	resp, err := http.Get("http://" + d.metrics.Addr + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`tnd_probes_total{trigger="route"} 1`,
+		"tnd_trusted 1",
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Errorf("missing %s in metrics:\n%s", want, b)
+		}
+	}
+
+	// test stop
+	d.signals <- syscall.SIGTERM
+	if got := <-exit; got != exitOK {
+		t.Errorf("got %d, want %d", got, exitOK)
+	}
+
+	// test start error
+	d, err = newDaemon(&daemonFlags{metrics: "invalid address"}, tnd.NewFileConfig())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := d.run(); got != exitError {
+		t.Errorf("got %d, want %d", got, exitError)
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/https"
+)
+
+// fingerprintFlags are the command line flags of the fingerprint command.
+type fingerprintFlags struct {
+	url     string
+	timeout time.Duration
+	snippet bool
+	pin     bool
+}
+
+// parseFingerprintFlags parses the command line arguments args of the
+// fingerprint command.
+func parseFingerprintFlags(args []string) (*fingerprintFlags, error) {
+	f := &fingerprintFlags{}
+	flags := flag.NewFlagSet("tnd fingerprint", flag.ContinueOnError)
+	flags.DurationVar(&f.timeout, "timeout", 10*time.Second,
+		"timeout of the connection")
+	flags.BoolVar(&f.snippet, "snippet", false,
+		"print config file snippet of the server")
+	flags.BoolVar(&f.pin, "pin", false,
+		"use public key pin instead of certificate hash in snippet")
+	flags.Usage = func() {
+		_, _ = fmt.Fprintln(flags.Output(),
+			"Usage: tnd fingerprint [flags] <url>")
+		flags.PrintDefaults()
+	}
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+	if flags.NArg() != 1 {
+		return nil, errors.New("expected exactly one url")
+	}
+	f.url = flags.Arg(0)
+	return f, nil
+}
+
+// printCertificates prints the certificates certs of the server at url to w.
+func printCertificates(w io.Writer, url string, certs []*https.CertificateInfo) {
+	for i, c := range certs {
+		switch i {
+		case 0:
+			_, _ = fmt.Fprintln(w, "server certificate:")
+		case 1:
+			_, _ = fmt.Fprintln(w, "certificate chain:")
+		}
+		_, _ = fmt.Fprintf(w, "  subject:     %s\n", c.Subject)
+		_, _ = fmt.Fprintf(w, "  issuer:      %s\n", c.Issuer)
+		_, _ = fmt.Fprintf(w, "  not before:  %s\n", c.NotBefore.UTC().Format(time.RFC3339))
+		_, _ = fmt.Fprintf(w, "  not after:   %s\n", c.NotAfter.UTC().Format(time.RFC3339))
+		_, _ = fmt.Fprintf(w, "  fingerprint: %s\n", c.Fingerprint)
+		_, _ = fmt.Fprintf(w, "  pin:         %s\n", c.Pin)
+		if i < len(certs)-1 {
+			_, _ = fmt.Fprintln(w)
+		}
+	}
+	if len(certs) > 0 {
+		_, _ = fmt.Fprintf(w, "\nhttpsservers: %s:%s\n", url, certs[0].Fingerprint)
+	}
+}
+
+// printSnippet prints a config file snippet of the server at url with hash
+// to w.
+func printSnippet(w io.Writer, url, hash string) error {
+	b, err := json.MarshalIndent(struct {
+		URL    string   `json:"url"`
+		Hashes []string `json:"hashes"`
+	}{url, []string{hash}}, "", "\t")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// runFingerprint runs the fingerprint command with the command line
+// arguments args and returns the exit code.
+func runFingerprint(args []string) int {
+	flags, err := parseFingerprintFlags(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return exitOK
+	}
+	if err != nil {
+		log.WithError(err).Error("TND invalid command line")
+		return exitUsage
+	}
+
+	// get certificates
+	certs, err := https.Inspect(context.Background(), &net.Dialer{},
+		flags.url, flags.timeout)
+	if err == nil && len(certs) == 0 {
+		err = errors.New("no certificates")
+	}
+	if err != nil {
+		log.WithError(err).Error("TND could not get server certificates")
+		return exitError
+	}
+
+	// print certificates or snippet
+	if !flags.snippet {
+		printCertificates(stdout, flags.url, certs)
+		return exitOK
+	}
+	hash := certs[0].Fingerprint
+	if flags.pin {
+		hash = certs[0].Pin
+	}
+	if err := printSnippet(stdout, flags.url, hash); err != nil {
+		log.WithError(err).Error("TND could not print snippet")
+		return exitError
+	}
+	return exitOK
+}
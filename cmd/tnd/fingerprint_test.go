@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telekom-mms/tnd/internal/https"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// TestParseFingerprintFlags tests parseFingerprintFlags.
+func TestParseFingerprintFlags(t *testing.T) {
+	// test defaults
+	f, err := parseFingerprintFlags([]string{"https://test.example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &fingerprintFlags{
+		url:     "https://test.example.com",
+		timeout: 10 * time.Second,
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("got %v, want %v", f, want)
+	}
+
+	// test all flags
+	f, err = parseFingerprintFlags([]string{
+		"-timeout", "5s", "-snippet", "-pin", "https://test.example.com",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &fingerprintFlags{
+		url:     "https://test.example.com",
+		timeout: 5 * time.Second,
+		snippet: true,
+		pin:     true,
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("got %v, want %v", f, want)
+	}
+
+	// test help and invalid
+	if _, err := parseFingerprintFlags([]string{"-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Errorf("got %v, want help error", err)
+	}
+	for _, invalid := range [][]string{
+		{},
+		{"https://test1.example.com", "https://test2.example.com"},
+		{"-unknown", "https://test.example.com"},
+	} {
+		if _, err := parseFingerprintFlags(invalid); err == nil {
+			t.Errorf("%v: flags should be invalid", invalid)
+		}
+	}
+}
+
+// TestPrintCertificates tests printCertificates.
+func TestPrintCertificates(t *testing.T) {
+	b := &bytes.Buffer{}
+	printCertificates(b, "https://test.example.com", []*https.CertificateInfo{
+		{
+			Subject:     "CN=test.example.com",
+			Issuer:      "CN=Test CA",
+			NotBefore:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+			NotAfter:    time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Fingerprint: "abc",
+			Pin:         "pin-sha256:def",
+		},
+		{Subject: "CN=Test CA", Issuer: "CN=Test CA"},
+	})
+	got := b.String()
+	for _, want := range []string{
+		"server certificate:\n  subject:     CN=test.example.com\n",
+		"  issuer:      CN=Test CA\n",
+		"  not before:  2025-01-01T00:00:00Z\n",
+		"  not after:   2026-01-01T00:00:00Z\n",
+		"  fingerprint: abc\n",
+		"  pin:         pin-sha256:def\n",
+		"certificate chain:\n  subject:     CN=Test CA\n",
+		"httpsservers: https://test.example.com:abc\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+}
+
+// TestRunFingerprint tests runFingerprint.
+func TestRunFingerprint(t *testing.T) {
+	defer func(w io.Writer) { stdout = w }(stdout)
+	b := &bytes.Buffer{}
+	stdout = b
+
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	cert := ts.Certificate()
+	sha := sha256.Sum256(cert.Raw)
+	hash := hex.EncodeToString(sha[:])
+	sha = sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := tnd.PinPrefix + base64.StdEncoding.EncodeToString(sha[:])
+
+	// test certificates
+	if got := run([]string{"fingerprint", ts.URL}); got != exitOK {
+		t.Errorf("got %d, want %d", got, exitOK)
+	}
+	for _, want := range []string{
+		"fingerprint: " + hash,
+		"pin:         " + pin,
+		"httpsservers: " + ts.URL + ":" + hash,
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("got %q, want %q", b.String(), want)
+		}
+	}
+
+	// test snippets, must be valid config file servers
+	for _, test := range []struct {
+		args []string
+		hash string
+	}{
+		{[]string{"-snippet"}, hash},
+		{[]string{"-snippet", "-pin"}, pin},
+	} {
+		b.Reset()
+		args := append(append([]string{"fingerprint"}, test.args...), ts.URL)
+		if got := run(args); got != exitOK {
+			t.Errorf("%v: got %d, want %d", test.args, got, exitOK)
+		}
+		server := &tnd.Server{}
+		if err := json.Unmarshal(b.Bytes(), server); err != nil {
+			t.Fatal(err)
+		}
+		if server.URL != ts.URL || !reflect.DeepEqual(server.Hashes, []string{test.hash}) {
+			t.Errorf("%v: invalid snippet %q", test.args, b.String())
+		}
+		path := writeConfigFile(t, `{"servers": [`+b.String()+`]}`)
+		if _, err := tnd.LoadConfig(path); err != nil {
+			t.Errorf("%v: invalid snippet: %v", test.args, err)
+		}
+	}
+
+	// test errors
+	for _, test := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-h"}, exitOK},
+		{[]string{}, exitUsage},
+		{[]string{"http://test.example.com"}, exitError},
+		{[]string{"https://127.0.0.1:1"}, exitError},
+	} {
+		if got := run(append([]string{"fingerprint"}, test.args...)); got != test.want {
+			t.Errorf("%v: got %d, want %d", test.args, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Tnd is the trusted network detection daemon.
+
+Usage:
+
+	tnd [flags]
+	tnd check [flags]
+	tnd fingerprint [flags] <url>
+
+Without command, tnd runs the daemon. The check command runs a single probe
+of the trusted servers, prints the result and exits with code 0 for a trusted
+network, 1 for an untrusted network and 2 on errors. The fingerprint command
+connects to the https server at url and prints the fingerprints, subjects,
+issuers and validity of its certificates or a config file snippet for the
+server.
+
+The daemon loads its configuration from the config file, the TND_*
+environment variables and the command line flags with increasing precedence.
+It logs the trusted network state, reloads its configuration on SIGHUP and
+stops on SIGTERM and SIGINT. When started by systemd as a notify service, it
+reports readiness, reloads and its status to systemd. With -dbus session or
+-dbus system, it provides the trusted network state and a Probe method as
+D-Bus service com.telekom_mms.tnd on the session or system bus. With -socket,
+it serves a JSON API on a Unix domain socket with the permissions set with
+-socketmode and -socketgroup. With -metrics, it serves Prometheus metrics of
+its probes on the HTTP address.
+*/
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// Exit codes.
+const (
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// commonFlags are the command line flags of all commands.
+type commonFlags struct {
+	configFile   string
+	httpsServers string
+	logLevel     log.Level
+	output       string
+
+	// log level as string
+	level string
+}
+
+// define defines the common flags in flags with the default log level.
+func (c *commonFlags) define(flags *flag.FlagSet, logLevel string) {
+	flags.StringVar(&c.configFile, "config", "", "JSON config file")
+	flags.StringVar(&c.httpsServers, "httpsservers", "",
+		"comma-separated list of trusted https server url:hash pairs, "+
+			"repeat url for multiple hashes of the same server; "+
+			"overrides servers in config file and TND_SERVERS")
+	flags.StringVar(&c.level, "loglevel", logLevel,
+		"log level: panic, fatal, error, warn, info, debug or trace")
+	flags.StringVar(&c.output, "output", "text",
+		"output format of results: text or json")
+}
+
+// parse parses the common flags after flags.Parse().
+func (c *commonFlags) parse(flags *flag.FlagSet) error {
+	if flags.NArg() > 0 {
+		return errors.New("unexpected arguments")
+	}
+
+	level, err := log.ParseLevel(c.level)
+	if err != nil {
+		return err
+	}
+	c.logLevel = level
+
+	if c.output != "text" && c.output != "json" {
+		return fmt.Errorf("unknown output format %q", c.output)
+	}
+	return nil
+}
+
+// loadConfig loads the configuration from the config file, the environment
+// variables and the command line flags.
+func (c *commonFlags) loadConfig() (*tnd.FileConfig, error) {
+	config := tnd.NewFileConfig()
+	if c.configFile != "" {
+		f, err := tnd.LoadConfig(c.configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = f
+	}
+	if err := config.LoadEnv(); err != nil {
+		return nil, err
+	}
+	if c.httpsServers != "" {
+		servers, err := tnd.ParseServers(c.httpsServers)
+		if err != nil {
+			return nil, err
+		}
+		config.Servers = servers
+	}
+	if len(config.Servers) == 0 {
+		return nil, errors.New("no trusted https servers")
+	}
+	return config, nil
+}
+
+// printJSON prints v as JSON object in a single line to w.
+func printJSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// run runs the tnd command with the command line arguments args and returns
+// the exit code.
+func run(args []string) int {
+	if len(args) > 0 {
+		switch args[0] {
+		case "check":
+			return runCheck(args[1:])
+		case "fingerprint":
+			return runFingerprint(args[1:])
+		}
+	}
+	return runDaemon(args)
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testHash returns a valid hash of data for testing.
+func testHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// writeConfigFile writes the config file data to a temporary directory and
+// returns its path.
+func writeConfigFile(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "tnd.json")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestCommonFlagsLoadConfig tests loadConfig of commonFlags.
+func TestCommonFlagsLoadConfig(t *testing.T) {
+	hash1 := testHash("hash1")
+	hash2 := testHash("hash2")
+	path := writeConfigFile(t, `{
+		"wait_check": "2s",
+		"servers": [{"url": "https://file.example.com", "hashes": ["`+hash1+`"]}]
+	}`)
+
+	// test config file
+	c, err := (&commonFlags{configFile: path}).loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Config.WaitCheck != 2*time.Second ||
+		len(c.Servers) != 1 || c.Servers[0].URL != "https://file.example.com" {
+		t.Errorf("invalid config: %v", c)
+	}
+
+	// test invalid
+	for _, invalid := range []*commonFlags{
+		{},
+		{configFile: filepath.Join(t.TempDir(), "missing.json")},
+		{configFile: writeConfigFile(t, `{"unknown": true}`)},
+		{httpsServers: "https://flag.example.com:invalid"},
+	} {
+		if _, err := invalid.loadConfig(); err == nil {
+			t.Errorf("%v: config should be invalid", invalid)
+		}
+	}
+
+	// test environment overrides config file
+	t.Setenv("TND_WAIT_CHECK", "3s")
+	t.Setenv("TND_SERVERS", "https://env.example.com:"+hash2)
+	c, err = (&commonFlags{configFile: path}).loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Config.WaitCheck != 3*time.Second ||
+		len(c.Servers) != 1 || c.Servers[0].URL != "https://env.example.com" {
+		t.Errorf("invalid config: %v", c)
+	}
+
+	// test flags override environment
+	c, err = (&commonFlags{
+		configFile:   path,
+		httpsServers: "https://flag.example.com:" + hash1,
+	}).loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Servers) != 1 || c.Servers[0].URL != "https://flag.example.com" {
+		t.Errorf("invalid config: %v", c)
+	}
+
+}
+
+// TestRun tests run.
+func TestRun(t *testing.T) {
+	for _, test := range []struct {
+		args []string
+		want int
+	}{
+		{[]string{"-h"}, exitOK},
+		{[]string{"-unknown"}, exitUsage},
+		{[]string{}, exitError},
+		{[]string{"-config", filepath.Join(t.TempDir(), "missing.json")}, exitError},
+	} {
+		if got := run(test.args); got != test.want {
+			t.Errorf("%v: got %d, want %d", test.args, got, test.want)
+		}
+	}
+}
@@ -4,39 +4,60 @@ Tnd is a TND example.
 package main
 
 import (
+	"encoding/json"
 	"flag"
-	"strings"
+	"os"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/telekom-mms/tnd/pkg/tnd"
 )
 
 var (
+	// config file and whether it is watched
+	configFile  string
+	watchConfig bool
+
+	// output format
+	output string
+
 	// parsed https servers
-	httpsServers = make(map[string]string)
+	httpsServers []*tnd.Server
 )
 
 // parseCommandLine parses the command line arguments
 func parseCommandLine() {
 	// define and parse command line arguments
 	hs := flag.String("httpsservers", "",
-		"comma-separated list of trusted https server url:hash pairs")
+		"comma-separated list of trusted https server url:hash pairs, "+
+			"hash is a certificate hash with optional sha256:, sha384: or sha512: "+
+			"prefix or a pin-sha256:, pin-sha384: or pin-sha512: public key pin, "+
+			"repeat url for multiple hashes of the same server; "+
+			"overrides servers in config file and TND_SERVERS")
+	flag.StringVar(&configFile, "config", "", "JSON config file")
+	flag.BoolVar(&watchConfig, "watchconfig", false,
+		"reload config file on changes, cannot be used with -httpsservers")
+	flag.StringVar(&output, "output", "text",
+		"output format of results: text or json")
 	flag.Parse()
 
+	// check output format
+	if output != "text" && output != "json" {
+		log.WithField("output", output).Fatal("TND output format invalid")
+	}
+
 	// parse https servers
 	if *hs == "" {
-		log.Fatal("TND https servers not specified")
+		return
 	}
-	for _, s := range strings.Split(*hs, ",") {
-		i := strings.LastIndex(s, ":")
-		if i == -1 || len(s) < i+2 {
-			// TODO: check a minimum hash length?
-			log.Fatal("TND https server hash invalid")
-		}
-		url := s[:i]
-		hash := strings.ToLower(s[i+1:])
-		httpsServers[url] = hash
+	if watchConfig {
+		// reloads of the config file would drop the https servers
+		log.Fatal("TND https servers cannot be used with -watchconfig")
+	}
+	servers, err := tnd.ParseServers(*hs)
+	if err != nil {
+		log.WithError(err).Fatal("TND https servers invalid")
 	}
+	httpsServers = servers
 }
 
 func main() {
@@ -46,16 +67,58 @@ func main() {
 	// parse command line arguments
 	parseCommandLine()
 
+	// load config file and environment variables
+	config := tnd.NewFileConfig()
+	if configFile != "" {
+		c, err := tnd.LoadConfig(configFile)
+		if err != nil {
+			log.WithError(err).Fatal("TND config file invalid")
+		}
+		config = c
+	}
+	if err := config.LoadEnv(); err != nil {
+		log.WithError(err).Fatal("TND environment invalid")
+	}
+	if httpsServers != nil {
+		config.Servers = httpsServers
+	}
+	if len(config.Servers) == 0 {
+		log.Fatal("TND https servers not specified")
+	}
+
 	// create tnd
-	t := tnd.NewDetector(tnd.NewConfig())
+	t := tnd.NewDetector(config.Config)
+	t.SetDialer(config.Dialer)
 
 	// set trusted https servers
-	t.SetServers(httpsServers)
+	if err := t.SetTrustedServers(config.Servers); err != nil {
+		log.WithError(err).Fatal("TND https servers invalid")
+	}
+
+	// watch config file
+	if watchConfig && configFile != "" {
+		t.SetConfigFile(configFile)
+	}
 
 	// start tnd
 	if err := t.Start(); err != nil {
 		log.Fatal(err)
 	}
+	go func() {
+		for err := range t.Errors() {
+			log.WithError(err).Error("TND error")
+		}
+	}()
+	if output == "json" {
+		// print each result as json object in a single line
+		enc := json.NewEncoder(os.Stdout)
+		for r := range t.DetailedResults() {
+			if err := enc.Encode(r); err != nil {
+				log.WithError(err).Error("TND could not print result")
+			}
+		}
+		return
+	}
 	for r := range t.Results() {
 		log.WithField("trusted", r).Info("TND result")
 	}
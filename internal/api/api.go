@@ -0,0 +1,294 @@
+// Package api contains the JSON API of the TND on a Unix domain socket.
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// MaxResults is the number of results kept for the results request.
+const MaxResults = 100
+
+// State is the trusted network state.
+type State struct {
+	// Trusted is whether the network is trusted.
+	Trusted bool `json:"trusted"`
+
+	// Server is the trusted server in a trusted network.
+	Server string `json:"server,omitempty"`
+
+	// Since is the time of the last state change.
+	Since time.Time `json:"since"`
+
+	// Servers are the URLs of the trusted servers.
+	Servers []string `json:"servers"`
+}
+
+// Server is the API server on a Unix domain socket.
+type Server struct {
+	tnd   tnd.TND
+	path  string
+	mode  fs.FileMode
+	group int
+
+	listener net.Listener
+	server   *http.Server
+	done     chan struct{}
+
+	mu      sync.Mutex
+	results []*tnd.Result
+	since   time.Time
+	events  map[chan *tnd.Result]struct{}
+}
+
+// writeJSON writes v as JSON response with status code.
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Debug("TND could not write API response")
+	}
+}
+
+// writeError writes an error response with status code.
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// state returns the state for the result r, caller must hold mu.
+func (s *Server) state(r *tnd.Result) *State {
+	servers := []string{}
+	for _, server := range s.tnd.GetTrustedServers() {
+		servers = append(servers, server.URL)
+	}
+	return &State{
+		Trusted: r.Trusted,
+		Server:  r.Server,
+		Since:   s.since,
+		Servers: servers,
+	}
+}
+
+// lastResult returns the last result, caller must hold mu.
+func (s *Server) lastResult() *tnd.Result {
+	if len(s.results) == 0 {
+		return nil
+	}
+	return s.results[len(s.results)-1]
+}
+
+// handleState handles state requests.
+func (s *Server) handleState(w http.ResponseWriter, _ *http.Request) {
+	s.mu.Lock()
+	r := s.lastResult()
+	if r == nil {
+		s.mu.Unlock()
+		writeError(w, http.StatusServiceUnavailable, errors.New("no result yet"))
+		return
+	}
+	state := s.state(r)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, state)
+}
+
+// handleResults handles results requests with the optional number n of
+// last results.
+func (s *Server) handleResults(w http.ResponseWriter, req *http.Request) {
+	n := MaxResults
+	if v := req.URL.Query().Get("n"); v != "" {
+		i, err := strconv.Atoi(v)
+		if err != nil || i < 0 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid n: %q", v))
+			return
+		}
+		n = i
+	}
+
+	s.mu.Lock()
+	n = min(n, len(s.results))
+	results := append([]*tnd.Result{}, s.results[len(s.results)-n:]...)
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusOK, results)
+}
+
+// handleProbe handles probe requests.
+func (s *Server) handleProbe(w http.ResponseWriter, _ *http.Request) {
+	log.Debug("TND API probe request")
+	s.tnd.Probe()
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// handleEvents handles event requests and streams the current state and
+// all state changes as JSON lines.
+func (s *Server) handleEvents(w http.ResponseWriter, req *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, errors.New("streaming not supported"))
+		return
+	}
+
+	// subscribe to state changes and get current state
+	events := make(chan *tnd.Result, 16)
+	s.mu.Lock()
+	s.events[events] = struct{}{}
+	var state *State
+	if r := s.lastResult(); r != nil {
+		state = s.state(r)
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.events, events)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+	enc := json.NewEncoder(w)
+	for {
+		if state != nil {
+			if err := enc.Encode(state); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+
+		select {
+		case r, ok := <-events:
+			if !ok {
+				// subscriber too slow
+				return
+			}
+			s.mu.Lock()
+			state = s.state(r)
+			s.mu.Unlock()
+		case <-req.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// Update adds the result r and sends state changes to the event streams.
+func (s *Server) Update(r *tnd.Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	last := s.lastResult()
+	changed := last == nil || last.Trusted != r.Trusted
+	if changed {
+		s.since = r.Time
+		if s.since.IsZero() {
+			s.since = time.Now()
+		}
+	}
+	if len(s.results) == MaxResults {
+		s.results = append(s.results[:0], s.results[1:]...)
+	}
+	s.results = append(s.results, r)
+	if !changed {
+		return
+	}
+
+	for events := range s.events {
+		select {
+		case events <- r:
+		default:
+			// drop slow subscriber
+			close(events)
+			delete(s.events, events)
+		}
+	}
+}
+
+// listen creates the Unix domain socket with the configured permissions.
+func (s *Server) listen() (net.Listener, error) {
+	// remove stale socket
+	if fi, err := os.Lstat(s.path); err == nil && fi.Mode().Type() == fs.ModeSocket {
+		if err := os.Remove(s.path); err != nil {
+			return nil, err
+		}
+	}
+
+	l, err := net.Listen("unix", s.path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(s.path, s.mode); err != nil {
+		_ = l.Close()
+		return nil, err
+	}
+	if s.group >= 0 {
+		if err := os.Chown(s.path, -1, s.group); err != nil {
+			_ = l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// Start starts the API server on the socket.
+func (s *Server) Start() error {
+	l, err := s.listen()
+	if err != nil {
+		return fmt.Errorf("could not create API socket: %w", err)
+	}
+	s.listener = l
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", s.handleState)
+	mux.HandleFunc("GET /results", s.handleResults)
+	mux.HandleFunc("POST /probe", s.handleProbe)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	s.server = &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		if err := s.server.Serve(l); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.WithError(err).Error("TND API server stopped")
+		}
+	}()
+	return nil
+}
+
+// Stop stops the API server and removes the socket.
+func (s *Server) Stop() {
+	close(s.done)
+	if err := s.server.Close(); err != nil {
+		log.WithError(err).Error("TND could not stop API server")
+	}
+
+	// make sure the listener is closed and the socket is removed, even if
+	// the server did not start serving yet
+	_ = s.listener.Close()
+}
+
+// NewServer returns a new API server for the TND t on the Unix domain socket
+// path with the file mode and group id of the socket; a negative group keeps
+// the default group.
+func NewServer(path string, mode fs.FileMode, group int, t tnd.TND) *Server {
+	return &Server{
+		tnd:    t,
+		path:   path,
+		mode:   mode,
+		group:  group,
+		done:   make(chan struct{}),
+		events: make(map[chan *tnd.Result]struct{}),
+	}
+}
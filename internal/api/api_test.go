@@ -0,0 +1,238 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/telekom-mms/tnd/pkg/tnd"
+	"github.com/telekom-mms/tnd/pkg/tnd/tndtest"
+)
+
+// testClient returns a http client for the Unix domain socket path.
+func testClient(path string) *http.Client {
+	return &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", path)
+		},
+	}}
+}
+
+// testServer starts a test server with a test detector.
+func testServer(t *testing.T, probes chan struct{}) (*Server, *http.Client) {
+	path := filepath.Join(t.TempDir(), "tnd.sock")
+	s := NewServer(path, 0600, -1, &tndtest.Detector{Funcs: tndtest.Funcs{
+		GetTrustedServers: func() []*tnd.Server {
+			return []*tnd.Server{{URL: "https://test.example.com"}}
+		},
+		Probe: func() { probes <- struct{}{} },
+	}})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(s.Stop)
+	return s, testClient(path)
+}
+
+// getJSON gets url with client and decodes the JSON response into v.
+func getJSON(t *testing.T, client *http.Client, url string, v any) int {
+	t.Helper()
+	resp, err := client.Get("http://tnd" + url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+// TestServerState tests state requests of Server.
+func TestServerState(t *testing.T) {
+	s, client := testServer(t, nil)
+
+	// test without result
+	e := map[string]string{}
+	if code := getJSON(t, client, "/state", &e); code != http.StatusServiceUnavailable ||
+		e["error"] == "" {
+		t.Errorf("got %d %v, want error", code, e)
+	}
+
+	// test with results
+	now := time.Now().Truncate(time.Second)
+	s.Update(&tnd.Result{Trusted: true, Server: "https://test.example.com", Time: now})
+	s.Update(&tnd.Result{Trusted: true, Server: "https://test.example.com", Time: now.Add(time.Minute)})
+	state := &State{}
+	if code := getJSON(t, client, "/state", state); code != http.StatusOK ||
+		!state.Trusted || state.Server != "https://test.example.com" ||
+		!state.Since.Equal(now) || len(state.Servers) != 1 {
+		t.Errorf("got %d %v, want trusted state", code, state)
+	}
+}
+
+// TestServerResults tests results requests of Server.
+func TestServerResults(t *testing.T) {
+	s, client := testServer(t, nil)
+
+	for i := range MaxResults + 10 {
+		s.Update(&tnd.Result{Trusted: i%2 == 0, Trigger: tnd.TriggerTimer})
+	}
+
+	// test all and last n results
+	for query, want := range map[string]int{
+		"":        MaxResults,
+		"?n=0":    0,
+		"?n=3":    3,
+		"?n=99":   99,
+		"?n=1000": MaxResults,
+	} {
+		var results []*struct{ Trusted bool }
+		if code := getJSON(t, client, "/results"+query, &results); code != http.StatusOK ||
+			len(results) != want {
+			t.Errorf("%s: got %d %d results, want %d", query, code, len(results), want)
+		}
+		if want > 0 && results[len(results)-1].Trusted {
+			t.Errorf("%s: last result should be untrusted", query)
+		}
+	}
+
+	// test invalid
+	for _, query := range []string{"?n=-1", "?n=invalid"} {
+		e := map[string]string{}
+		if code := getJSON(t, client, "/results"+query, &e); code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want bad request", query, code)
+		}
+	}
+}
+
+// TestServerProbe tests probe requests of Server.
+func TestServerProbe(t *testing.T) {
+	probes := make(chan struct{}, 1)
+	_, client := testServer(t, probes)
+
+	resp, err := client.Post("http://tnd/probe", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		t.Errorf("got %d, want %d", resp.StatusCode, http.StatusAccepted)
+	}
+	select {
+	case <-probes:
+	default:
+		t.Error("probe not triggered")
+	}
+
+	// test invalid method
+	resp, err = client.Get("http://tnd/probe")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("got %d, want %d", resp.StatusCode, http.StatusMethodNotAllowed)
+	}
+}
+
+// TestServerEvents tests event requests of Server.
+func TestServerEvents(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tnd.sock")
+	s := NewServer(path, 0600, -1, &tndtest.Detector{})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	client := testClient(path)
+	s.Update(&tnd.Result{Trusted: false})
+
+	resp, err := client.Get("http://tnd/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	lines := bufio.NewScanner(resp.Body)
+	wantState := func(want bool) {
+		t.Helper()
+		if !lines.Scan() {
+			t.Fatalf("missing event: %v", lines.Err())
+		}
+		state := &State{}
+		if err := json.Unmarshal(lines.Bytes(), state); err != nil {
+			t.Fatal(err)
+		}
+		if state.Trusted != want {
+			t.Errorf("got %t, want %t", state.Trusted, want)
+		}
+	}
+
+	// test current state and state changes only
+	wantState(false)
+	s.Update(&tnd.Result{Trusted: false})
+	s.Update(&tnd.Result{Trusted: true})
+	wantState(true)
+	s.Update(&tnd.Result{Trusted: true})
+	s.Update(&tnd.Result{Trusted: false})
+	wantState(false)
+
+	// test stop
+	s.Stop()
+	if lines.Scan() {
+		t.Errorf("unexpected event: %s", lines.Text())
+	}
+}
+
+// TestServerStartStop tests Start and Stop of Server.
+func TestServerStartStop(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tnd.sock")
+
+	// test stale socket
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = l.Close()
+
+	s := NewServer(path, 0660, os.Getgid(), &tndtest.Detector{})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0660 || fi.Mode().Type() != fs.ModeSocket {
+		t.Errorf("invalid socket mode: %v", fi.Mode())
+	}
+
+	// test stop removes socket
+	s.Stop()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("socket should be removed: %v", err)
+	}
+
+	// test invalid path
+	s = NewServer(filepath.Join(dir, "does/not/exist"), 0600, -1, &tndtest.Detector{})
+	if err := s.Start(); err == nil {
+		t.Error("invalid path should return error")
+	}
+
+	// test regular file is not removed
+	file := filepath.Join(dir, "file")
+	if err := os.WriteFile(file, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	s = NewServer(file, 0600, -1, &tndtest.Detector{})
+	if err := s.Start(); err == nil {
+		t.Error("existing file should return error")
+	}
+}
@@ -0,0 +1,172 @@
+// Package dbusapi contains the D-Bus service of the TND.
+package dbusapi
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/godbus/dbus/v5/introspect"
+	"github.com/godbus/dbus/v5/prop"
+	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// D-Bus object settings.
+const (
+	Name      = "com.telekom_mms.tnd"
+	Path      = dbus.ObjectPath("/com/telekom_mms/tnd")
+	Interface = "com.telekom_mms.tnd.Detector"
+)
+
+// Properties of the D-Bus object.
+const (
+	// PropertyTrusted is whether the network is trusted, type "b".
+	PropertyTrusted = "Trusted"
+
+	// PropertyTrustedServer is the URL of the trusted server in a
+	// trusted network, type "s".
+	PropertyTrustedServer = "TrustedServer"
+
+	// PropertyServers are the URLs of the trusted servers, type "as".
+	PropertyServers = "Servers"
+)
+
+// Methods of the D-Bus object.
+const (
+	// MethodProbe triggers a probe of the trusted servers.
+	MethodProbe = Interface + ".Probe"
+)
+
+// ErrNameTaken is the error when the D-Bus name is owned by another
+// connection.
+var ErrNameTaken = errors.New("dbus name already taken")
+
+// detector is the exported D-Bus object with the methods.
+type detector struct {
+	tnd tnd.TND
+}
+
+// Probe triggers a probe of the trusted servers.
+func (d *detector) Probe() *dbus.Error {
+	log.Debug("TND D-Bus probe request")
+	d.tnd.Probe()
+	return nil
+}
+
+// Service is a D-Bus service that exposes the state of a TND.
+type Service struct {
+	conn  *dbus.Conn
+	tnd   tnd.TND
+	props *prop.Properties
+}
+
+// serverURLs returns the URLs of the trusted servers of the TND.
+func (s *Service) serverURLs() []string {
+	urls := []string{}
+	for _, server := range s.tnd.GetTrustedServers() {
+		urls = append(urls, server.URL)
+	}
+	return urls
+}
+
+// set sets property name to value and emits PropertiesChanged if the value
+// changed.
+func (s *Service) set(name string, value any) {
+	if equal(s.props.GetMust(Interface, name), value) {
+		return
+	}
+	s.props.SetMust(Interface, name, value)
+}
+
+// equal returns whether the property values a and b are equal.
+func equal(a, b any) bool {
+	as, aok := a.([]string)
+	bs, bok := b.([]string)
+	if aok && bok {
+		return slices.Equal(as, bs)
+	}
+	return a == b
+}
+
+// Update updates the properties with the result r and the current trusted
+// servers of the TND.
+func (s *Service) Update(r *tnd.Result) {
+	s.set(PropertyTrusted, r.Trusted)
+	s.set(PropertyTrustedServer, r.Server)
+	s.set(PropertyServers, s.serverURLs())
+}
+
+// Start exports the D-Bus object and requests the D-Bus name.
+func (s *Service) Start() error {
+	props, err := prop.Export(s.conn, Path, prop.Map{
+		Interface: {
+			PropertyTrusted: {
+				Value: false,
+				Emit:  prop.EmitTrue,
+			},
+			PropertyTrustedServer: {
+				Value: "",
+				Emit:  prop.EmitTrue,
+			},
+			PropertyServers: {
+				Value: s.serverURLs(),
+				Emit:  prop.EmitTrue,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not export properties: %w", err)
+	}
+	s.props = props
+
+	if err := s.conn.Export(&detector{tnd: s.tnd}, Path, Interface); err != nil {
+		return fmt.Errorf("could not export methods: %w", err)
+	}
+
+	node := &introspect.Node{
+		Name: string(Path),
+		Interfaces: []introspect.Interface{
+			introspect.IntrospectData,
+			prop.IntrospectData,
+			{
+				Name:       Interface,
+				Methods:    introspect.Methods(&detector{}),
+				Properties: props.Introspection(Interface),
+			},
+		},
+	}
+	if err := s.conn.Export(introspect.NewIntrospectable(node), Path,
+		"org.freedesktop.DBus.Introspectable"); err != nil {
+		return fmt.Errorf("could not export introspection: %w", err)
+	}
+
+	reply, err := s.conn.RequestName(Name, dbus.NameFlagDoNotQueue)
+	if err != nil {
+		return fmt.Errorf("could not request name: %w", err)
+	}
+	if reply != dbus.RequestNameReplyPrimaryOwner {
+		return ErrNameTaken
+	}
+	return nil
+}
+
+// Stop releases the D-Bus name and closes the D-Bus connection.
+func (s *Service) Stop() {
+	if _, err := s.conn.ReleaseName(Name); err != nil {
+		log.WithError(err).Error("TND could not release D-Bus name")
+	}
+	if err := s.conn.Close(); err != nil {
+		log.WithError(err).Error("TND could not close D-Bus connection")
+	}
+}
+
+// NewService returns a new D-Bus service for the TND t on the D-Bus
+// connection conn.
+func NewService(conn *dbus.Conn, t tnd.TND) *Service {
+	return &Service{
+		conn: conn,
+		tnd:  t,
+	}
+}
@@ -0,0 +1,163 @@
+package dbusapi
+
+import (
+	"bufio"
+	"errors"
+	"os/exec"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/telekom-mms/tnd/pkg/tnd"
+	"github.com/telekom-mms/tnd/pkg/tnd/tndtest"
+)
+
+// testBus starts a private session bus and returns its address.
+func testBus(t *testing.T) string {
+	if _, err := exec.LookPath("dbus-daemon"); err != nil {
+		t.Skip("dbus-daemon not found")
+	}
+	cmd := exec.Command("dbus-daemon", "--session", "--nofork", "--print-address=1")
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+	})
+
+	address, err := bufio.NewReader(out).ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+	return strings.TrimSpace(address)
+}
+
+// testConn returns a new connection to the bus at address.
+func testConn(t *testing.T, address string) *dbus.Conn {
+	conn, err := dbus.Connect(address)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+// TestService tests Service.
+func TestService(t *testing.T) {
+	address := testBus(t)
+
+	// start service with test detector
+	servers := []*tnd.Server{{URL: "https://test1.example.com"}}
+	probes := make(chan struct{}, 1)
+	s := NewService(testConn(t, address), &tndtest.Detector{Funcs: tndtest.Funcs{
+		GetTrustedServers: func() []*tnd.Server { return servers },
+		Probe:             func() { probes <- struct{}{} },
+	}})
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	// connect client and subscribe to property changes
+	client := testConn(t, address)
+	if err := client.AddMatchSignal(
+		dbus.WithMatchObjectPath(Path),
+		dbus.WithMatchInterface("org.freedesktop.DBus.Properties"),
+		dbus.WithMatchMember("PropertiesChanged"),
+	); err != nil {
+		t.Fatal(err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	client.Signal(signals)
+	obj := client.Object(Name, Path)
+
+	// test initial properties
+	v, err := obj.GetProperty(Interface + "." + PropertyTrusted)
+	if err != nil || v.Value() != false {
+		t.Errorf("invalid initial trusted: %v, %v", v, err)
+	}
+	v, err = obj.GetProperty(Interface + "." + PropertyServers)
+	if err != nil || !slices.Equal(v.Value().([]string), []string{"https://test1.example.com"}) {
+		t.Errorf("invalid initial servers: %v, %v", v, err)
+	}
+
+	// test probe
+	if err := obj.Call(MethodProbe, 0).Err; err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-probes:
+	case <-time.After(10 * time.Second):
+		t.Error("probe not triggered")
+	}
+
+	// test update
+	wantChanged := func(want map[string]any) {
+		t.Helper()
+		select {
+		case sig := <-signals:
+			changed := sig.Body[1].(map[string]dbus.Variant)
+			if len(changed) != len(want) {
+				t.Errorf("got %v, want %v", changed, want)
+			}
+			for name, value := range want {
+				if !equal(changed[name].Value(), value) {
+					t.Errorf("%s: got %v, want %v", name, changed[name], value)
+				}
+			}
+		case <-time.After(10 * time.Second):
+			t.Fatalf("missing properties changed signal %v", want)
+		}
+	}
+	s.Update(&tnd.Result{Trusted: true, Server: "https://test1.example.com"})
+	wantChanged(map[string]any{PropertyTrusted: true})
+	wantChanged(map[string]any{PropertyTrustedServer: "https://test1.example.com"})
+
+	// test update with same state and changed servers
+	servers = []*tnd.Server{{URL: "https://test2.example.com"}}
+	s.Update(&tnd.Result{Trusted: true, Server: "https://test1.example.com"})
+	wantChanged(map[string]any{PropertyServers: []string{"https://test2.example.com"}})
+	select {
+	case sig := <-signals:
+		t.Errorf("unexpected signal %v", sig)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	v, err = obj.GetProperty(Interface + "." + PropertyTrusted)
+	if err != nil || v.Value() != true {
+		t.Errorf("invalid trusted: %v, %v", v, err)
+	}
+
+	// test introspection
+	var xml string
+	if err := obj.Call("org.freedesktop.DBus.Introspectable.Introspect", 0).Store(&xml); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(xml, "Probe") || !strings.Contains(xml, PropertyTrusted) {
+		t.Errorf("invalid introspection: %s", xml)
+	}
+}
+
+// TestServiceNameTaken tests Start of Service with a taken name.
+func TestServiceNameTaken(t *testing.T) {
+	address := testBus(t)
+
+	d := &tndtest.Detector{}
+	s1 := NewService(testConn(t, address), d)
+	if err := s1.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer s1.Stop()
+
+	s2 := NewService(testConn(t, address), d)
+	if err := s2.Start(); !errors.Is(err, ErrNameTaken) {
+		t.Errorf("got %v, want %v", err, ErrNameTaken)
+	}
+}
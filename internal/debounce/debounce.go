@@ -0,0 +1,131 @@
+// Package debounce contains components for debouncing bursts of events.
+package debounce
+
+import "time"
+
+// Clock provides the current time and timers, e.g., a fake clock in tests.
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer is a timer created by a Clock.
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+	Reset(d time.Duration) bool
+}
+
+// realTimer is a Timer based on time.Timer.
+type realTimer struct {
+	t *time.Timer
+}
+
+// C returns the channel of the timer.
+func (t *realTimer) C() <-chan time.Time {
+	return t.t.C
+}
+
+// Stop stops the timer.
+func (t *realTimer) Stop() bool {
+	return t.t.Stop()
+}
+
+// Reset resets the timer to duration d.
+func (t *realTimer) Reset(d time.Duration) bool {
+	return t.t.Reset(d)
+}
+
+// realClock is a Clock based on package time.
+type realClock struct{}
+
+// Now returns the current time.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer returns a new timer that expires after duration d.
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{t: time.NewTimer(d)}
+}
+
+// RealClock is the Clock based on package time.
+var RealClock Clock = realClock{}
+
+// Debouncer coalesces bursts of events. After an event, it waits until no
+// new event is added for the quiet period, but at most for the max delay
+// after the first event of the burst, and then fires once over its channel.
+// It is not safe for concurrent use and is meant to be used in a select
+// loop together with C().
+type Debouncer struct {
+	clock    Clock
+	quiet    time.Duration
+	maxDelay time.Duration
+
+	timer   Timer
+	pending bool
+	first   time.Time
+	events  int
+}
+
+// Add adds an event and returns whether it is the first event of a burst.
+func (d *Debouncer) Add() bool {
+	now := d.clock.Now()
+	first := !d.pending
+	if first {
+		d.pending = true
+		d.first = now
+		d.events = 0
+	}
+	d.events++
+
+	// wait for quiet period, but not longer than max delay after the
+	// first event
+	wait := max(min(d.quiet, d.first.Add(d.maxDelay).Sub(now)), 0)
+	if d.timer == nil {
+		d.timer = d.clock.NewTimer(wait)
+	} else {
+		d.timer.Stop()
+		d.timer.Reset(wait)
+	}
+	return first
+}
+
+// C returns the channel that receives when a burst of events is over, or a
+// nil channel if no events are pending. After receiving from the channel,
+// Fire must be called.
+func (d *Debouncer) C() <-chan time.Time {
+	if !d.pending {
+		return nil
+	}
+	return d.timer.C()
+}
+
+// Fire ends the current burst of events and returns its number of events.
+func (d *Debouncer) Fire() int {
+	d.pending = false
+	return d.events
+}
+
+// Pending returns whether events are pending.
+func (d *Debouncer) Pending() bool {
+	return d.pending
+}
+
+// Stop stops the Debouncer and drops pending events.
+func (d *Debouncer) Stop() {
+	if d.timer != nil {
+		d.timer.Stop()
+	}
+	d.pending = false
+}
+
+// NewDebouncer returns a new Debouncer with clock, the quiet period and the
+// max delay.
+func NewDebouncer(clock Clock, quiet, maxDelay time.Duration) *Debouncer {
+	return &Debouncer{
+		clock:    clock,
+		quiet:    quiet,
+		maxDelay: maxDelay,
+	}
+}
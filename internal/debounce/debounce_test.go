@@ -0,0 +1,133 @@
+package debounce
+
+import (
+	"testing"
+	"time"
+)
+
+// fired returns whether the Debouncer d fired.
+func fired(d *Debouncer) bool {
+	select {
+	case <-d.C():
+		return true
+	default:
+		return false
+	}
+}
+
+// TestDebouncerQuiet tests the quiet period of Debouncer.
+func TestDebouncerQuiet(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	d := NewDebouncer(clock, time.Second, 10*time.Second)
+
+	// test not pending
+	if d.C() != nil || d.Pending() || fired(d) {
+		t.Fatal("debouncer should not be pending")
+	}
+
+	// test burst of events
+	if !d.Add() {
+		t.Error("first event should start burst")
+	}
+	for range 3 {
+		clock.Advance(900 * time.Millisecond)
+		if d.Add() {
+			t.Error("event should not start burst")
+		}
+		if fired(d) {
+			t.Fatal("debouncer should not fire during burst")
+		}
+	}
+
+	// test quiet period
+	clock.Advance(999 * time.Millisecond)
+	if fired(d) {
+		t.Fatal("debouncer should not fire before quiet period")
+	}
+	clock.Advance(time.Millisecond)
+	if !fired(d) {
+		t.Fatal("debouncer should fire after quiet period")
+	}
+	if n := d.Fire(); n != 4 {
+		t.Errorf("got %d events, want 4", n)
+	}
+	if d.Pending() {
+		t.Error("debouncer should not be pending")
+	}
+
+	// test new burst
+	if !d.Add() {
+		t.Error("first event should start new burst")
+	}
+	clock.Advance(time.Second)
+	if !fired(d) || d.Fire() != 1 {
+		t.Error("debouncer should fire single event")
+	}
+}
+
+// TestDebouncerMaxDelay tests the max delay of Debouncer.
+func TestDebouncerMaxDelay(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	d := NewDebouncer(clock, time.Second, 3*time.Second)
+
+	// test continuous events
+	d.Add()
+	for range 5 {
+		clock.Advance(500 * time.Millisecond)
+		if fired(d) {
+			t.Fatal("debouncer should not fire before max delay")
+		}
+		d.Add()
+	}
+	clock.Advance(499 * time.Millisecond)
+	if fired(d) {
+		t.Fatal("debouncer should not fire before max delay")
+	}
+	clock.Advance(time.Millisecond)
+	if !fired(d) {
+		t.Fatal("debouncer should fire after max delay")
+	}
+	if n := d.Fire(); n != 6 {
+		t.Errorf("got %d events, want 6", n)
+	}
+
+	// test event after max delay fires immediately
+	d = NewDebouncer(clock, time.Second, 0)
+	d.Add()
+	if !fired(d) {
+		t.Error("debouncer should fire immediately")
+	}
+}
+
+// TestDebouncerStop tests Stop of Debouncer.
+func TestDebouncerStop(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	d := NewDebouncer(clock, time.Second, 3*time.Second)
+
+	// test not started
+	d.Stop()
+
+	// test pending events
+	d.Add()
+	d.Stop()
+	clock.Advance(time.Minute)
+	if d.Pending() || fired(d) {
+		t.Error("debouncer should be stopped")
+	}
+}
+
+// TestDebouncerRealClock tests Debouncer with the real clock.
+func TestDebouncerRealClock(t *testing.T) {
+	d := NewDebouncer(RealClock, 10*time.Millisecond, time.Second)
+	start := time.Now()
+	d.Add()
+	d.Add()
+	select {
+	case <-d.C():
+	case <-time.After(10 * time.Second):
+		t.Fatal("debouncer should fire")
+	}
+	if n := d.Fire(); n != 2 || time.Since(start) < 10*time.Millisecond {
+		t.Errorf("got %d events after %v", n, time.Since(start))
+	}
+}
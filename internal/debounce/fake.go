@@ -0,0 +1,105 @@
+package debounce
+
+import (
+	"sync"
+	"time"
+)
+
+// fakeTimer is a Timer of a FakeClock.
+type fakeTimer struct {
+	clock    *FakeClock
+	c        chan time.Time
+	deadline time.Time
+	active   bool
+}
+
+// C returns the channel of the timer.
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.c
+}
+
+// stop stops the timer and drains its channel, the clock must be locked.
+func (t *fakeTimer) stop() bool {
+	active := t.active
+	t.active = false
+	select {
+	case <-t.c:
+	default:
+	}
+	return active
+}
+
+// Stop stops the timer.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	return t.stop()
+}
+
+// Reset resets the timer to duration d.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	active := t.stop()
+	t.deadline = t.clock.now.Add(d)
+	t.active = true
+	t.clock.fire()
+	return active
+}
+
+// FakeClock is a Clock for testing that only advances with Advance.
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+// fire fires all expired timers, the clock must be locked.
+func (c *FakeClock) fire() {
+	for _, t := range c.timers {
+		if t.active && !t.deadline.After(c.now) {
+			t.active = false
+			t.c <- t.deadline
+		}
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+// NewTimer returns a new timer that expires after duration d.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	t := &fakeTimer{
+		clock:    c,
+		c:        make(chan time.Time, 1),
+		deadline: c.now.Add(d),
+		active:   true,
+	}
+	c.timers = append(c.timers, t)
+	c.fire()
+	return t
+}
+
+// Advance advances the clock by d and fires the expired timers.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	c.fire()
+}
+
+// NewFakeClock returns a new FakeClock with the current time now.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
@@ -6,7 +6,7 @@ import (
 	"slices"
 
 	"github.com/fsnotify/fsnotify"
-	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/logging"
 )
 
 // Watcher is the file watcher interface.
@@ -22,6 +22,7 @@ type Watch struct {
 	probes  chan struct{}
 	done    chan struct{}
 	closed  chan struct{}
+	logger  logging.Logger
 }
 
 // sendProbe sends a probe request over the probe channel.
@@ -37,7 +38,8 @@ func (w *Watch) start() {
 	defer close(w.closed)
 	defer func() {
 		if err := w.watcher.Close(); err != nil {
-			log.WithError(err).Error("TND could not stop file watcher")
+			w.logger.Error("TND could not stop file watcher",
+				logging.FieldError, err)
 		}
 	}()
 
@@ -52,17 +54,17 @@ func (w *Watch) start() {
 				return
 			}
 			if slices.Contains(w.files, event.Name) {
-				log.WithFields(log.Fields{
-					"name": event.Name,
-					"op":   event.Op,
-				}).Debug("TND got resolv.conf file event")
+				w.logger.Debug("TND got resolv.conf file event",
+					logging.FieldFile, event.Name,
+					logging.FieldOp, event.Op)
 				w.sendProbe()
 			}
 		case err, ok := <-w.watcher.Errors:
 			if !ok {
 				return
 			}
-			log.WithError(err).Error("TND got error file event")
+			w.logger.Error("TND got error file event",
+				logging.FieldError, err)
 		case <-w.done:
 			return
 		}
@@ -82,7 +84,8 @@ func (w *Watch) Start() error {
 	// create watcher
 	watcher, err := fsnotifyNewWatcher()
 	if err != nil {
-		log.WithError(err).Error("TND could not create file watcher")
+		w.logger.Error("TND could not create file watcher",
+			logging.FieldError, err)
 		return err
 	}
 
@@ -90,10 +93,10 @@ func (w *Watch) Start() error {
 	for _, f := range w.files {
 		p := filepath.Dir(f)
 		if err := watcherAdd(watcher, p); err != nil {
-			log.WithError(err).
-				WithField("file", f).
-				WithField("folder", p).
-				Error("TND could not add folder to file watcher")
+			w.logger.Error("TND could not add folder to file watcher",
+				logging.FieldFile, f,
+				logging.FieldFolder, p,
+				logging.FieldError, err)
 			_ = watcher.Close()
 			return err
 		}
@@ -111,12 +114,13 @@ func (w *Watch) Stop() {
 	<-w.closed
 }
 
-// NewWatch returns a new Watch.
-func NewWatch(probes chan struct{}, files []string) *Watch {
+// NewWatch returns a new Watch that logs with logger.
+func NewWatch(probes chan struct{}, files []string, logger logging.Logger) *Watch {
 	return &Watch{
 		files:  files,
 		probes: probes,
 		done:   make(chan struct{}),
 		closed: make(chan struct{}),
+		logger: logger,
 	}
 }
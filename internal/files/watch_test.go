@@ -7,6 +7,7 @@ import (
 	"testing"
 
 	"github.com/fsnotify/fsnotify"
+	"github.com/telekom-mms/tnd/internal/logging"
 )
 
 // testFiles are resolv.conf files for testing.
@@ -20,7 +21,7 @@ var testFiles = []string{
 func TestWatchStartEvents(t *testing.T) {
 	// create watcher
 	probes := make(chan struct{})
-	fw := NewWatch(probes, testFiles)
+	fw := NewWatch(probes, testFiles, logging.Default())
 	w, err := fsnotify.NewWatcher()
 	if err != nil {
 		t.Fatal(err)
@@ -58,7 +59,7 @@ func TestWatchStartStop(t *testing.T) {
 		}
 
 		// test error
-		fw := NewWatch(probes, testFiles)
+		fw := NewWatch(probes, testFiles, logging.Default())
 		if err := fw.Start(); err == nil {
 			t.Errorf("start should fail")
 		}
@@ -76,7 +77,7 @@ func TestWatchStartStop(t *testing.T) {
 		}
 
 		// test error
-		fw := NewWatch(probes, testFiles)
+		fw := NewWatch(probes, testFiles, logging.Default())
 		if err := fw.Start(); err == nil {
 			t.Errorf("start should fail")
 		}
@@ -89,7 +90,7 @@ func TestWatchStartStop(t *testing.T) {
 		file := filepath.Join(dir, "resolv.conf")
 
 		// test without errors
-		fw := NewWatch(probes, []string{file})
+		fw := NewWatch(probes, []string{file}, logging.Default())
 		if err := fw.Start(); err != nil {
 			t.Errorf("start should not fail: %v", err)
 		}
@@ -100,7 +101,7 @@ func TestWatchStartStop(t *testing.T) {
 // TestNewWatch tests NewWatch.
 func TestNewWatch(t *testing.T) {
 	probes := make(chan struct{})
-	fw := NewWatch(probes, testFiles)
+	fw := NewWatch(probes, testFiles, logging.Default())
 	if !reflect.DeepEqual(fw.files, testFiles) {
 		t.Errorf("got %v, want %v", fw.files, testFiles)
 	}
@@ -0,0 +1,208 @@
+package https
+
+import (
+	"crypto"
+	_ "crypto/sha256" // register hash function
+	_ "crypto/sha512" // register hash functions
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// PinPrefix is the prefix of hashes that pin the SHA-256 hash of the
+// certificate's public key (SubjectPublicKeyInfo) as in HPKP pin-sha256
+// instead of the SHA-256 hash of the full certificate. The hash after the
+// prefix is hex or base64 encoded.
+const PinPrefix = "pin-sha256:"
+
+// pinPrefix is the prefix of hash algorithms for public key pins.
+const pinPrefix = "pin-"
+
+// ErrInvalidHash is the error when a hash cannot be parsed.
+var ErrInvalidHash = errors.New("invalid hash")
+
+// algorithms are the supported hash algorithms.
+var algorithms = map[string]crypto.Hash{
+	"sha256": crypto.SHA256,
+	"sha384": crypto.SHA384,
+	"sha512": crypto.SHA512,
+}
+
+// Fingerprint is a parsed hash of a certificate or its public key.
+type Fingerprint struct {
+	// Algorithm is the name of the hash algorithm, e.g., sha256.
+	Algorithm string
+
+	// PublicKey indicates whether the hash is a hash of the
+	// certificate's public key (SubjectPublicKeyInfo) instead of the
+	// full certificate.
+	PublicKey bool
+
+	// Hash is the hash value.
+	Hash []byte
+
+	// legacy indicates a hex encoded SHA-256 certificate hash without
+	// algorithm prefix.
+	legacy bool
+}
+
+// sum returns the hash of cert.
+func (f *Fingerprint) sum(cert *x509.Certificate) []byte {
+	h := algorithms[f.Algorithm].New()
+	if f.PublicKey {
+		_, _ = h.Write(cert.RawSubjectPublicKeyInfo)
+	} else {
+		_, _ = h.Write(cert.Raw)
+	}
+	return h.Sum(nil)
+}
+
+// format returns the hash value sum formatted like f. Certificate hashes are
+// hex encoded, public key pins are base64 encoded.
+func (f *Fingerprint) format(sum []byte) string {
+	switch {
+	case f.legacy:
+		return hex.EncodeToString(sum)
+	case f.PublicKey:
+		return pinPrefix + f.Algorithm + ":" +
+			base64.StdEncoding.EncodeToString(sum)
+	}
+	return f.Algorithm + ":" + hex.EncodeToString(sum)
+}
+
+// String returns the fingerprint as string.
+func (f *Fingerprint) String() string {
+	return f.format(f.Hash)
+}
+
+// Match returns whether cert matches the fingerprint.
+func (f *Fingerprint) Match(cert *x509.Certificate) bool {
+	return string(f.sum(cert)) == string(f.Hash)
+}
+
+// Observe returns the fingerprint of cert formatted like f.
+func (f *Fingerprint) Observe(cert *x509.Certificate) string {
+	return f.format(f.sum(cert))
+}
+
+// decodeHash decodes the hex or base64 encoded hash s with length size.
+func decodeHash(s string, size int) ([]byte, error) {
+	// hex encoded hashes of the wrong length could also be decoded as
+	// valid base64, so reject hashes consisting only of hex digits here
+	if b, err := hex.DecodeString(s); err == nil {
+		if len(b) == size {
+			return b, nil
+		}
+		return nil, fmt.Errorf("%w: %q is a hex encoded hash of "+
+			"length %d, want length %d", ErrInvalidHash, s, len(b), size)
+	}
+	for _, enc := range []*base64.Encoding{
+		base64.StdEncoding,
+		base64.RawStdEncoding,
+	} {
+		if b, err := enc.DecodeString(s); err == nil && len(b) == size {
+			return b, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %q is not a hex or base64 encoded "+
+		"hash of length %d", ErrInvalidHash, s, size)
+}
+
+// ParseFingerprint parses the fingerprint s. The fingerprint is either a hex
+// encoded SHA-256 certificate hash without prefix or a hex or base64 encoded
+// hash with an algorithm prefix: "sha256:", "sha384:" or "sha512:" for
+// certificate hashes and "pin-sha256:", "pin-sha384:" or "pin-sha512:" for
+// public key pins.
+func ParseFingerprint(s string) (*Fingerprint, error) {
+	// legacy hex encoded sha256 certificate hash
+	prefix, value, found := strings.Cut(s, ":")
+	if !found {
+		b, err := hex.DecodeString(s)
+		if err != nil || len(b) != crypto.SHA256.Size() {
+			return nil, fmt.Errorf("%w: %q is not a hex encoded "+
+				"sha256 hash", ErrInvalidHash, s)
+		}
+		return &Fingerprint{
+			Algorithm: "sha256",
+			Hash:      b,
+			legacy:    true,
+		}, nil
+	}
+
+	// hash with algorithm prefix
+	name, pin := strings.CutPrefix(strings.ToLower(prefix), pinPrefix)
+	alg, ok := algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown hash algorithm %q",
+			ErrInvalidHash, prefix)
+	}
+	b, err := decodeHash(value, alg.Size())
+	if err != nil {
+		return nil, err
+	}
+	return &Fingerprint{
+		Algorithm: name,
+		PublicKey: pin,
+		Hash:      b,
+	}, nil
+}
+
+// certFingerprint returns the hex encoded SHA-256 hash of cert.
+func certFingerprint(cert *x509.Certificate) string {
+	f := &Fingerprint{Algorithm: "sha256", legacy: true}
+	return f.Observe(cert)
+}
+
+// matchFingerprint returns whether cert matches the expected hash and the
+// observed fingerprint of cert.
+func matchFingerprint(cert *x509.Certificate, hash string) (bool, string) {
+	f, err := ParseFingerprint(hash)
+	if err != nil {
+		return false, certFingerprint(cert)
+	}
+	return f.Match(cert), f.Observe(cert)
+}
+
+// matchFingerprints returns whether cert matches one of the expected hashes
+// and the observed fingerprint of cert. The observed fingerprint is the one
+// of the matching hash or, if there is no match, of the first hash.
+func matchFingerprints(cert *x509.Certificate, hashes []string) (bool, string) {
+	fp := certFingerprint(cert)
+	for i, hash := range hashes {
+		ok, f := matchFingerprint(cert, hash)
+		if ok {
+			return true, f
+		}
+		if i == 0 {
+			fp = f
+		}
+	}
+	return false, fp
+}
+
+// matchCertificates returns whether one of the certificates certs matches
+// one of the expected hashes and the observed fingerprint. The observed
+// fingerprint is the one of the matching certificate or, if there is no
+// match, of the first certificate.
+func matchCertificates(certs []*x509.Certificate, hashes []string) (bool, string) {
+	_, fp := matchFingerprints(certs[0], hashes)
+	for _, cert := range certs {
+		if ok, f := matchFingerprints(cert, hashes); ok {
+			return true, f
+		}
+	}
+	return false, fp
+}
+
+// normalizeHash returns the normalized hash. Hex encoded certificate hashes
+// without prefix are converted to lower case, hashes with prefix may be
+// base64 encoded and case-sensitive and are therefore not modified.
+func normalizeHash(hash string) string {
+	if strings.Contains(hash, ":") {
+		return hash
+	}
+	return strings.ToLower(hash)
+}
@@ -0,0 +1,234 @@
+package https
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// testCert returns the certificate of a test https server.
+func testCert() *x509.Certificate {
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+	return ts.Certificate()
+}
+
+// TestDecodeHash tests decodeHash.
+func TestDecodeHash(t *testing.T) {
+	sha := sha256.Sum256([]byte("test"))
+
+	// test valid
+	for _, valid := range []string{
+		hex.EncodeToString(sha[:]),
+		base64.StdEncoding.EncodeToString(sha[:]),
+		base64.RawStdEncoding.EncodeToString(sha[:]),
+	} {
+		got, err := decodeHash(valid, sha256.Size)
+		if err != nil || string(got) != string(sha[:]) {
+			t.Errorf("%s: got %x, %v, want %x", valid, got, err, sha)
+		}
+	}
+
+	// test invalid
+	for _, invalid := range []string{
+		"",
+		"invalid",
+		hex.EncodeToString(sha[:16]),
+		base64.StdEncoding.EncodeToString(sha[:16]),
+	} {
+		if _, err := decodeHash(invalid, sha256.Size); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%s: decode should fail", invalid)
+		}
+	}
+}
+
+// TestParseFingerprint tests ParseFingerprint.
+func TestParseFingerprint(t *testing.T) {
+	sha256Sum := sha256.Sum256([]byte("test"))
+	sha384Sum := sha512.Sum384([]byte("test"))
+	sha512Sum := sha512.Sum512([]byte("test"))
+	sha256Hex := hex.EncodeToString(sha256Sum[:])
+	sha384Hex := hex.EncodeToString(sha384Sum[:])
+	sha512Base64 := base64.StdEncoding.EncodeToString(sha512Sum[:])
+
+	// test valid
+	for _, test := range []struct {
+		s         string
+		algorithm string
+		publicKey bool
+		hash      []byte
+		str       string
+	}{
+		{sha256Hex, "sha256", false, sha256Sum[:], sha256Hex},
+		{"SHA256:" + sha256Hex, "sha256", false, sha256Sum[:], "sha256:" + sha256Hex},
+		{"sha384:" + sha384Hex, "sha384", false, sha384Sum[:], "sha384:" + sha384Hex},
+		{"sha512:" + sha512Base64, "sha512", false, sha512Sum[:],
+			"sha512:" + hex.EncodeToString(sha512Sum[:])},
+		{"pin-sha256:" + sha256Hex, "sha256", true, sha256Sum[:],
+			"pin-sha256:" + base64.StdEncoding.EncodeToString(sha256Sum[:])},
+		{"pin-sha512:" + sha512Base64, "sha512", true, sha512Sum[:],
+			"pin-sha512:" + sha512Base64},
+	} {
+		f, err := ParseFingerprint(test.s)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.s, err)
+			continue
+		}
+		if f.Algorithm != test.algorithm ||
+			f.PublicKey != test.publicKey ||
+			string(f.Hash) != string(test.hash) {
+			t.Errorf("%s: got %v", test.s, f)
+		}
+		if f.String() != test.str {
+			t.Errorf("%s: got %s, want %s", test.s, f, test.str)
+		}
+	}
+
+	// test invalid
+	for _, invalid := range []string{
+		"",
+		"invalid",
+		"abcdef",
+		sha384Hex,
+		"md5:" + sha256Hex,
+		"sha384:" + sha256Hex,
+		"pin-sha256:" + sha384Hex,
+		"sha512:invalid",
+		"pin-sha256:",
+	} {
+		if _, err := ParseFingerprint(invalid); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%s: parse should fail", invalid)
+		}
+	}
+}
+
+// TestFingerprintMatchObserve tests Match and Observe of Fingerprint.
+func TestFingerprintMatchObserve(t *testing.T) {
+	cert := testCert()
+	sha384Sum := sha512.Sum384(cert.Raw)
+	sha512Sum := sha512.Sum512(cert.RawSubjectPublicKeyInfo)
+	for _, fp := range []string{
+		"sha384:" + hex.EncodeToString(sha384Sum[:]),
+		"pin-sha512:" + base64.StdEncoding.EncodeToString(sha512Sum[:]),
+	} {
+		f, err := ParseFingerprint(fp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !f.Match(cert) {
+			t.Errorf("%s: should match", fp)
+		}
+		if f.Observe(cert) != fp {
+			t.Errorf("got %s, want %s", f.Observe(cert), fp)
+		}
+		if f.Match(newTestPKI(t).leaf) {
+			t.Errorf("%s: should not match other certificate", fp)
+		}
+	}
+}
+
+// TestMatchFingerprint tests matchFingerprint.
+func TestMatchFingerprint(t *testing.T) {
+	cert := testCert()
+	certHash := sha256.Sum256(cert.Raw)
+	pinHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	certHex := hex.EncodeToString(certHash[:])
+	pinBase64 := PinPrefix + base64.StdEncoding.EncodeToString(pinHash[:])
+
+	for _, test := range []struct {
+		hash string
+		ok   bool
+		fp   string
+	}{
+		{certHex, true, certHex},
+		{"invalid", false, certHex},
+		{pinBase64, true, pinBase64},
+		{PinPrefix + hex.EncodeToString(pinHash[:]), true, pinBase64},
+		{PinPrefix + base64.StdEncoding.EncodeToString(certHash[:]), false, pinBase64},
+		{PinPrefix + "invalid", false, certHex},
+		{"sha256:" + certHex, true, "sha256:" + certHex},
+	} {
+		ok, fp := matchFingerprint(cert, test.hash)
+		if ok != test.ok || fp != test.fp {
+			t.Errorf("%s: got %t, %s, want %t, %s",
+				test.hash, ok, fp, test.ok, test.fp)
+		}
+	}
+}
+
+// TestMatchFingerprints tests matchFingerprints.
+func TestMatchFingerprints(t *testing.T) {
+	cert := testCert()
+	certHash := sha256.Sum256(cert.Raw)
+	pinHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	otherHash := sha256.Sum256([]byte("other"))
+	certHex := hex.EncodeToString(certHash[:])
+	otherHex := hex.EncodeToString(otherHash[:])
+	pinBase64 := PinPrefix + base64.StdEncoding.EncodeToString(pinHash[:])
+	otherPin := PinPrefix + base64.StdEncoding.EncodeToString(otherHash[:])
+
+	for _, test := range []struct {
+		hashes []string
+		ok     bool
+		fp     string
+	}{
+		{nil, false, certHex},
+		{[]string{otherHex}, false, certHex},
+		{[]string{otherPin}, false, pinBase64},
+		{[]string{otherHex, certHex}, true, certHex},
+		{[]string{otherHex, pinBase64}, true, pinBase64},
+		{[]string{otherPin, otherHex}, false, pinBase64},
+	} {
+		ok, fp := matchFingerprints(cert, test.hashes)
+		if ok != test.ok || fp != test.fp {
+			t.Errorf("%v: got %t, %s, want %t, %s",
+				test.hashes, ok, fp, test.ok, test.fp)
+		}
+	}
+}
+
+// TestMatchCertificates tests matchCertificates.
+func TestMatchCertificates(t *testing.T) {
+	pki := newTestPKI(t)
+	certs := []*x509.Certificate{pki.leaf, pki.intermediate}
+	leafHash := certFingerprint(pki.leaf)
+	intermediateHash := certFingerprint(pki.intermediate)
+
+	for _, test := range []struct {
+		hashes []string
+		ok     bool
+		fp     string
+	}{
+		{[]string{"invalid"}, false, leafHash},
+		{[]string{leafHash}, true, leafHash},
+		{[]string{intermediateHash}, true, intermediateHash},
+		{[]string{"invalid", intermediateHash}, true, intermediateHash},
+	} {
+		ok, fp := matchCertificates(certs, test.hashes)
+		if ok != test.ok || fp != test.fp {
+			t.Errorf("%v: got %t, %s, want %t, %s",
+				test.hashes, ok, fp, test.ok, test.fp)
+		}
+	}
+}
+
+// TestNormalizeHash tests normalizeHash.
+func TestNormalizeHash(t *testing.T) {
+	for hash, want := range map[string]string{
+		"ABCDEF":           "abcdef",
+		PinPrefix + "AbC=": PinPrefix + "AbC=",
+		"sha256:AbC=":      "sha256:AbC=",
+	} {
+		got := normalizeHash(hash)
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
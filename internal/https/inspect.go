@@ -0,0 +1,78 @@
+package https
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/url"
+	"time"
+)
+
+// CertificateInfo is information about a certificate presented by a server.
+type CertificateInfo struct {
+	// Subject and Issuer are the subject and issuer of the certificate.
+	Subject string
+	Issuer  string
+
+	// NotBefore and NotAfter are the validity period of the certificate.
+	NotBefore time.Time
+	NotAfter  time.Time
+
+	// Fingerprint is the hex encoded SHA-256 hash of the certificate.
+	Fingerprint string
+
+	// Pin is the SHA-256 public key pin of the certificate's
+	// SubjectPublicKeyInfo with PinPrefix.
+	Pin string
+}
+
+// newCertificateInfo returns the certificate info of cert.
+func newCertificateInfo(cert *x509.Certificate) *CertificateInfo {
+	pin := &Fingerprint{Algorithm: "sha256", PublicKey: true}
+	return &CertificateInfo{
+		Subject:     cert.Subject.String(),
+		Issuer:      cert.Issuer.String(),
+		NotBefore:   cert.NotBefore,
+		NotAfter:    cert.NotAfter,
+		Fingerprint: certFingerprint(cert),
+		Pin:         pin.Observe(cert),
+	}
+}
+
+// Inspect connects to the https server at rawURL using dialer and returns
+// the certificates presented by the server, the server's certificate first.
+// The certificates are not verified. The connection is aborted when ctx is
+// done or the timeout expires.
+func Inspect(ctx context.Context, dialer *net.Dialer, rawURL string, timeout time.Duration) ([]*CertificateInfo, error) {
+	// get server address from url
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "https" || u.Hostname() == "" {
+		return nil, fmt.Errorf("%q is not a https url", rawURL)
+	}
+	port := u.Port()
+	if port == "" {
+		port = "443"
+	}
+	addr := net.JoinHostPort(u.Hostname(), port)
+
+	// connect to server
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	conn, err := dialTLS(ctx, dialer, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	// get certificates
+	certs := []*CertificateInfo{}
+	for _, cert := range conn.(*tls.Conn).ConnectionState().PeerCertificates {
+		certs = append(certs, newCertificateInfo(cert))
+	}
+	return certs, nil
+}
@@ -0,0 +1,68 @@
+package https
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telekom-mms/tnd/internal/logging"
+)
+
+// TestInspect tests Inspect.
+func TestInspect(t *testing.T) {
+	pki := newTestPKI(t)
+	ts := pki.newServer()
+	defer ts.Close()
+
+	// test server with chain
+	certs, err := Inspect(context.Background(), &net.Dialer{}, ts.URL, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(certs) != 2 {
+		t.Fatalf("got %d certificates, want 2", len(certs))
+	}
+	leaf := certs[0]
+	if leaf.Fingerprint != certFingerprint(pki.leaf) ||
+		leaf.Subject != pki.leaf.Subject.String() ||
+		leaf.Issuer != pki.intermediate.Subject.String() ||
+		!leaf.NotBefore.Equal(pki.leaf.NotBefore) ||
+		!leaf.NotAfter.Equal(pki.leaf.NotAfter) {
+		t.Errorf("invalid server certificate: %v", leaf)
+	}
+	if certs[1].Fingerprint != certFingerprint(pki.intermediate) {
+		t.Errorf("invalid intermediate certificate: %v", certs[1])
+	}
+
+	// inspected fingerprint and pin must be accepted by Check
+	for _, hash := range []string{leaf.Fingerprint, leaf.Pin} {
+		s, err := NewServer(ts.URL, hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if r := s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default()); !r.Trusted {
+			t.Errorf("%s: got %v, want trusted", hash, r)
+		}
+	}
+
+	// test invalid
+	hs := httptest.NewServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer hs.Close()
+
+	for _, invalid := range []string{
+		"",
+		"://invalid",
+		"http://test.example.com",
+		"https://",
+		"https" + strings.TrimPrefix(hs.URL, "http"),
+	} {
+		if _, err := Inspect(context.Background(), &net.Dialer{}, invalid, time.Second); err == nil {
+			t.Errorf("%s: inspect should fail", invalid)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package https
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testPKI is a test PKI with a root CA, an intermediate CA and a server
+// certificate for 127.0.0.1.
+type testPKI struct {
+	root            *x509.Certificate
+	rootPEM         []byte
+	intermediate    *x509.Certificate
+	intermediateKey *ecdsa.PrivateKey
+	leaf            *x509.Certificate
+	cert            tls.Certificate
+}
+
+// newTestCert creates a new test certificate from template signed by parent
+// and parentKey; if parent is nil, the certificate is self-signed.
+func newTestCert(t *testing.T, template, parent *x509.Certificate,
+	parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parent == nil {
+		parent, parentKey = template, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent,
+		&key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert, key
+}
+
+// newTestPKI returns a new test PKI.
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	notBefore := time.Now().Add(-time.Hour)
+	notAfter := time.Now().Add(time.Hour)
+
+	// root ca
+	root, rootKey := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test Root CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, nil, nil)
+
+	// intermediate ca
+	intermediate, intermediateKey := newTestCert(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(2),
+		Subject:               pkix.Name{CommonName: "Test Intermediate CA"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}, root, rootKey)
+
+	// server certificate
+	leaf, leafKey := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, intermediate, intermediateKey)
+
+	return &testPKI{
+		root:            root,
+		rootPEM:         pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw}),
+		intermediate:    intermediate,
+		intermediateKey: intermediateKey,
+		leaf:            leaf,
+		cert: tls.Certificate{
+			Certificate: [][]byte{leaf.Raw, intermediate.Raw},
+			PrivateKey:  leafKey,
+			Leaf:        leaf,
+		},
+	}
+}
+
+// forgedCert returns a self-signed server certificate for 127.0.0.1 that is
+// presented together with the intermediate CA of the test PKI, but is not
+// signed by it.
+func (p *testPKI) forgedCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	leaf, leafKey := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(4),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    p.leaf.NotBefore,
+		NotAfter:     p.leaf.NotAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, nil, nil)
+	return tls.Certificate{
+		Certificate: [][]byte{leaf.Raw, p.intermediate.Raw},
+		PrivateKey:  leafKey,
+		Leaf:        leaf,
+	}
+}
+
+// expiredCert returns an expired server certificate for 127.0.0.1 that is
+// signed by the intermediate CA of the test PKI and presented together with
+// it.
+func (p *testPKI) expiredCert(t *testing.T) tls.Certificate {
+	t.Helper()
+	leaf, leafKey := newTestCert(t, &x509.Certificate{
+		SerialNumber: big.NewInt(5),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    p.leaf.NotBefore,
+		NotAfter:     time.Now().Add(-time.Minute),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+	}, p.intermediate, p.intermediateKey)
+	return tls.Certificate{
+		Certificate: [][]byte{leaf.Raw, p.intermediate.Raw},
+		PrivateKey:  leafKey,
+		Leaf:        leaf,
+	}
+}
+
+// newTLSServer returns a new started test https server that uses cert.
+func newTLSServer(cert tls.Certificate) *httptest.Server {
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	ts.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	ts.StartTLS()
+	return ts
+}
+
+// newServer returns a new started test https server that uses the server
+// certificate and chain of the test PKI.
+func (p *testPKI) newServer() *httptest.Server {
+	return newTLSServer(p.cert)
+}
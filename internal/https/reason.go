@@ -0,0 +1,156 @@
+package https
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+)
+
+var (
+	// ErrNoTLS is the error when the connection to the server is not
+	// a tls connection.
+	ErrNoTLS = errors.New("no tls connection to https server")
+
+	// ErrHashMismatch is the error when the server's certificate hash
+	// does not match the expected hash.
+	ErrHashMismatch = errors.New("https server hash mismatch")
+
+	// ErrVerify is the error when the verification of the server's
+	// certificate chain or hostname failed.
+	ErrVerify = errors.New("https server certificate verification failed")
+)
+
+// Reason is the reason of a server check result.
+type Reason int
+
+// Reasons.
+const (
+	// ReasonNone is the reason if the server is trusted.
+	ReasonNone Reason = iota
+
+	// ReasonUnknown is the reason for all errors not covered by the
+	// other reasons.
+	ReasonUnknown
+
+	// ReasonDNS is the reason if the server's name could not be
+	// resolved.
+	ReasonDNS
+
+	// ReasonRefused is the reason if the connection to the server
+	// was refused.
+	ReasonRefused
+
+	// ReasonTimeout is the reason if the check timed out.
+	ReasonTimeout
+
+	// ReasonTLSHandshake is the reason if the tls handshake failed.
+	ReasonTLSHandshake
+
+	// ReasonNoTLS is the reason if no tls connection was created.
+	ReasonNoTLS
+
+	// ReasonHashMismatch is the reason if the server is reachable but
+	// its fingerprint does not match.
+	ReasonHashMismatch
+
+	// ReasonVerify is the reason if the server is reachable but the
+	// verification of its certificate chain or hostname failed.
+	ReasonVerify
+)
+
+// String returns reason as string.
+func (r Reason) String() string {
+	switch r {
+	case ReasonNone:
+		return "none"
+	case ReasonDNS:
+		return "dns"
+	case ReasonRefused:
+		return "refused"
+	case ReasonTimeout:
+		return "timeout"
+	case ReasonTLSHandshake:
+		return "tls handshake"
+	case ReasonNoTLS:
+		return "no tls"
+	case ReasonHashMismatch:
+		return "hash mismatch"
+	case ReasonVerify:
+		return "verify"
+	}
+	return "unknown"
+}
+
+// MarshalText returns reason as text.
+func (r Reason) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Unreachable returns whether the reason indicates that the server is
+// not reachable.
+func (r Reason) Unreachable() bool {
+	switch r {
+	case ReasonDNS, ReasonRefused, ReasonTimeout:
+		return true
+	}
+	return false
+}
+
+// handshakeError is an error during the tls handshake.
+type handshakeError struct {
+	err error
+}
+
+// Error returns the error as string.
+func (e *handshakeError) Error() string {
+	return "tls handshake error: " + e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *handshakeError) Unwrap() error {
+	return e.err
+}
+
+// getReason returns the reason for err.
+func getReason(err error) Reason {
+	if err == nil {
+		return ReasonNone
+	}
+
+	// dns errors
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return ReasonDNS
+	}
+
+	// timeouts
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) ||
+		(errors.As(err, &netErr) && netErr.Timeout()) {
+		return ReasonTimeout
+	}
+
+	// connection refused
+	if errors.Is(err, syscall.ECONNREFUSED) {
+		return ReasonRefused
+	}
+
+	// tls errors
+	var hsErr *handshakeError
+	if errors.As(err, &hsErr) {
+		return ReasonTLSHandshake
+	}
+
+	// no tls, hash and verification errors
+	switch {
+	case errors.Is(err, ErrNoTLS):
+		return ReasonNoTLS
+	case errors.Is(err, ErrHashMismatch):
+		return ReasonHashMismatch
+	case errors.Is(err, ErrVerify):
+		return ReasonVerify
+	}
+
+	return ReasonUnknown
+}
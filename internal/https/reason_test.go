@@ -0,0 +1,96 @@
+package https
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+)
+
+// TestReasonString tests String of Reason.
+func TestReasonString(t *testing.T) {
+	for reason, want := range map[Reason]string{
+		ReasonNone:         "none",
+		ReasonUnknown:      "unknown",
+		ReasonDNS:          "dns",
+		ReasonRefused:      "refused",
+		ReasonTimeout:      "timeout",
+		ReasonTLSHandshake: "tls handshake",
+		ReasonNoTLS:        "no tls",
+		ReasonHashMismatch: "hash mismatch",
+		ReasonVerify:       "verify",
+		Reason(1000):       "unknown",
+	} {
+		got := reason.String()
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+// TestReasonMarshalText tests MarshalText of Reason.
+func TestReasonMarshalText(t *testing.T) {
+	b, err := ReasonHashMismatch.MarshalText()
+	if err != nil || string(b) != "hash mismatch" {
+		t.Errorf("got %s, %v, want hash mismatch", b, err)
+	}
+}
+
+// TestReasonUnreachable tests Unreachable of Reason.
+func TestReasonUnreachable(t *testing.T) {
+	for reason, want := range map[Reason]bool{
+		ReasonNone:         false,
+		ReasonUnknown:      false,
+		ReasonDNS:          true,
+		ReasonRefused:      true,
+		ReasonTimeout:      true,
+		ReasonTLSHandshake: false,
+		ReasonNoTLS:        false,
+		ReasonHashMismatch: false,
+		ReasonVerify:       false,
+	} {
+		got := reason.Unreachable()
+		if got != want {
+			t.Errorf("%s: got %t, want %t", reason, got, want)
+		}
+	}
+}
+
+// TestGetReason tests getReason.
+func TestGetReason(t *testing.T) {
+	for _, test := range []struct {
+		err  error
+		want Reason
+	}{
+		{nil, ReasonNone},
+		{errors.New("test error"), ReasonUnknown},
+		{&net.DNSError{Err: "no such host"}, ReasonDNS},
+		{fmt.Errorf("dial: %w", syscall.ECONNREFUSED), ReasonRefused},
+		{context.DeadlineExceeded, ReasonTimeout},
+		{&net.OpError{Err: &net.DNSError{IsTimeout: true}}, ReasonDNS},
+		{&handshakeError{errors.New("test error")}, ReasonTLSHandshake},
+		{&handshakeError{context.DeadlineExceeded}, ReasonTimeout},
+		{ErrNoTLS, ReasonNoTLS},
+		{ErrHashMismatch, ReasonHashMismatch},
+		{fmt.Errorf("%w: test", ErrVerify), ReasonVerify},
+	} {
+		got := getReason(test.err)
+		if got != test.want {
+			t.Errorf("%v: got %s, want %s", test.err, got, test.want)
+		}
+	}
+}
+
+// TestHandshakeError tests handshakeError.
+func TestHandshakeError(t *testing.T) {
+	err := errors.New("test error")
+	hsErr := &handshakeError{err}
+	if hsErr.Error() != "tls handshake error: test error" {
+		t.Errorf("invalid error string: %s", hsErr)
+	}
+	if !errors.Is(hsErr, err) {
+		t.Errorf("handshake error should wrap error")
+	}
+}
@@ -2,78 +2,270 @@
 package https
 
 import (
-	"crypto/sha256"
+	"bytes"
+	"context"
 	"crypto/tls"
-	"encoding/hex"
+	"crypto/x509"
+	"errors"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
-	"strings"
+	"slices"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/logging"
 )
 
-// Server is a trusted https server and its certificate hash.
+// errInvalidCABundle is the error when the CA bundle contains no valid
+// certificates.
+var errInvalidCABundle = errors.New("invalid CA bundle")
+
+// Result is the result of a Server check.
+type Result struct {
+	// URL is the url of the checked server.
+	URL string
+
+	// Trusted indicates whether the server is trusted.
+	Trusted bool
+
+	// Reason is the reason why the server is not trusted.
+	Reason Reason
+
+	// Error is the error that caused the server to be not trusted.
+	Error error
+
+	// Latency is the duration of the check.
+	Latency time.Duration
+
+	// Fingerprint is the observed fingerprint of the server's
+	// certificate, if available.
+	Fingerprint string
+}
+
+// Server is a trusted https server and its accepted certificate hashes. See
+// ParseFingerprint for the hash formats.
 type Server struct {
-	URL  string
-	Hash string
+	URL    string
+	Hashes []string
+
+	// MatchChain enables matching the hashes against all certificates
+	// in the certificate chain instead of only the server's certificate.
+	MatchChain bool
+
+	// CABundle is an optional PEM encoded CA bundle the server's
+	// certificate chain must be verified against.
+	CABundle []byte
+
+	// VerifyHostname enables verification of the server's hostname.
+	VerifyHostname bool
+}
+
+// verifyChain verifies the certificate chain certs presented by the server
+// against the CAs in the server's CA bundle and returns the verified chains.
+func (s *Server) verifyChain(certs []*x509.Certificate) ([][]*x509.Certificate, error) {
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(s.CABundle) {
+		return nil, fmt.Errorf("%w: %w", ErrVerify, errInvalidCABundle)
+	}
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	chains, err := certs[0].Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrVerify, err)
+	}
+	return chains, nil
+}
+
+// presentedChain returns the certificates on the paths from the server's
+// certificate to the other certificates presented by the server. Only these
+// certificates are matched against the hashes without CA bundle, so a pinned
+// CA certificate that is just sent along with an unrelated certificate does
+// not match. The paths are built from the issuers and signatures of the
+// certificates only; like the server's certificate without MatchChain, their
+// validity periods are not checked without CA bundle, so an expired
+// certificate or a wrong clock does not break the pin. If no path can be
+// built, only the server's certificate is returned.
+func presentedChain(certs []*x509.Certificate) []*x509.Certificate {
+	chain := []*x509.Certificate{certs[0]}
+	for i := 0; i < len(chain); i++ {
+		for _, cert := range certs[1:] {
+			if slices.ContainsFunc(chain, cert.Equal) ||
+				!bytes.Equal(chain[i].RawIssuer, cert.RawSubject) ||
+				chain[i].CheckSignatureFrom(cert) != nil {
+				continue
+			}
+			chain = append(chain, cert)
+		}
+	}
+	return chain
+}
+
+// chainCertificates returns the unique certificates in chains.
+func chainCertificates(chains [][]*x509.Certificate) []*x509.Certificate {
+	certs := []*x509.Certificate{}
+	for _, chain := range chains {
+		for _, cert := range chain {
+			if !slices.ContainsFunc(certs, cert.Equal) {
+				certs = append(certs, cert)
+			}
+		}
+	}
+	return certs
+}
+
+// verify verifies the certificate chain certs presented by the server with
+// hostname and returns the certificates the hashes are matched against.
+func (s *Server) verify(certs []*x509.Certificate, hostname string) ([]*x509.Certificate, error) {
+	// verify hostname
+	if s.VerifyHostname {
+		if err := certs[0].VerifyHostname(hostname); err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrVerify, err)
+		}
+	}
+
+	// without CA bundle, use presented certificates on a path from the
+	// server's certificate
+	if len(s.CABundle) == 0 {
+		if s.MatchChain {
+			return presentedChain(certs), nil
+		}
+		return certs[:1], nil
+	}
+
+	// verify chain against CA bundle and use verified certificates
+	chains, err := s.verifyChain(certs)
+	if err != nil {
+		return nil, err
+	}
+	if !s.MatchChain {
+		return certs[:1], nil
+	}
+	return chainCertificates(chains), nil
+}
+
+// dialTLS connects to addr using dialer and runs the tls handshake. Errors
+// during the handshake are returned as handshakeError.
+func dialTLS(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	conn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	tlsConn := tls.Client(conn, &tls.Config{
+		ServerName:         host,
+		InsecureSkipVerify: true,
+	})
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		_ = conn.Close()
+		return nil, &handshakeError{err}
+	}
+	return tlsConn, nil
 }
 
-// Check probes the https server and checks the certificate hash using dialer.
-func (s *Server) Check(dialer *net.Dialer, timeout time.Duration) bool {
+// Check probes the https server and checks the certificate hashes using
+// dialer. The server is trusted if its certificate matches one of the hashes.
+// The check is aborted when ctx is done or the timeout expires. Messages are
+// logged with logger.
+func (s *Server) Check(ctx context.Context, dialer *net.Dialer, timeout time.Duration,
+	logger logging.Logger) *Result {
+	result := &Result{URL: s.URL}
+	start := time.Now()
+	defer func() {
+		result.Latency = time.Since(start)
+		result.Reason = getReason(result.Error)
+	}()
+
 	// connect to server
 	tr := &http.Transport{
-		DialContext:     dialer.DialContext,
-		TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		DialContext: dialer.DialContext,
+		DialTLSContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+			return dialTLS(ctx, dialer, network, addr)
+		},
 	}
+	defer tr.CloseIdleConnections()
 	client := &http.Client{
 		Transport: tr,
 		Timeout:   timeout,
 	}
-	r, err := client.Head(s.URL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.URL, nil)
+	if err != nil {
+		logger.Debug("TND http HEAD request creation error",
+			logging.FieldURL, s.URL, logging.FieldError, err)
+		result.Error = err
+		return result
+	}
+	r, err := client.Do(req)
 	if err != nil {
-		log.WithError(err).Debug("TND http HEAD request error")
-		return false
+		logger.Debug("TND http HEAD request error",
+			logging.FieldURL, s.URL, logging.FieldError, err)
+		result.Error = err
+		return result
 	}
 	defer func() {
 		if err := r.Body.Close(); err != nil {
-			log.WithError(err).Error("TND could not close http response body")
+			logger.Error("TND could not close http response body",
+				logging.FieldURL, s.URL, logging.FieldError, err)
 		}
 	}()
 	if _, err := io.Copy(io.Discard, r.Body); err != nil {
-		log.WithError(err).Error("TND could not read http response body")
+		logger.Error("TND could not read http response body",
+			logging.FieldURL, s.URL, logging.FieldError, err)
 	}
 
 	// make sure we created an tls connection
 	if r.TLS == nil {
-		log.WithField("error", "no tls connection to https server").
-			Debug("TND http connection error")
-		return false
+		logger.Debug("TND http connection error",
+			logging.FieldURL, s.URL, logging.FieldError, ErrNoTLS)
+		result.Error = ErrNoTLS
+		return result
 	}
 
-	// get certificate and the fingerprint
-	cert := r.TLS.PeerCertificates[0]
-	hash := sha256.Sum256(cert.Raw)
-	fp := hex.EncodeToString(hash[:])
+	// verify certificates
+	certs, err := s.verify(r.TLS.PeerCertificates, req.URL.Hostname())
+	if err != nil {
+		logger.Debug("TND https server verification error",
+			logging.FieldURL, s.URL, logging.FieldError, err)
+		result.Fingerprint = certFingerprint(r.TLS.PeerCertificates[0])
+		result.Error = err
+		return result
+	}
 
-	// check if fingerprint matches expected hash
-	if fp != s.Hash {
-		log.WithFields(log.Fields{
-			"got":  fp,
-			"want": s.Hash,
-		}).Debug("TND https server hash mismatch")
-		return false
+	// check if fingerprint of a certificate matches expected hash
+	ok, fp := matchCertificates(certs, s.Hashes)
+	result.Fingerprint = fp
+	if !ok {
+		logger.Debug("TND https server hash mismatch",
+			logging.FieldURL, s.URL, logging.FieldGot, fp,
+			logging.FieldWant, s.Hashes)
+		result.Error = ErrHashMismatch
+		return result
 	}
 
 	// all checks passed
-	return true
+	result.Trusted = true
+	return result
 }
 
-// NewServer returns a new Server with url and hash.
-func NewServer(url, hash string) *Server {
-	return &Server{
-		URL:  url,
-		Hash: strings.ToLower(hash),
+// NewServer returns a new Server with url and hashes. It returns an error if
+// one of the hashes is invalid.
+func NewServer(url string, hashes ...string) (*Server, error) {
+	s := &Server{URL: url}
+	for _, hash := range hashes {
+		if _, err := ParseFingerprint(hash); err != nil {
+			return nil, err
+		}
+		s.Hashes = append(s.Hashes, normalizeHash(hash))
 	}
+	return s, nil
 }
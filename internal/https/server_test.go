@@ -1,13 +1,22 @@
 package https
 
 import (
+	"context"
 	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/hex"
+	"errors"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/http/httptrace"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/telekom-mms/tnd/internal/logging"
 )
 
 // TestServerCheck tests Check of Server.
@@ -17,48 +26,297 @@ func TestServerCheck(t *testing.T) {
 		func(http.ResponseWriter, *http.Request) {}))
 	defer ts.Close()
 
+	cert := ts.Certificate()
+	sha := sha256.Sum256(cert.Raw)
+	hash := hex.EncodeToString(sha[:])
+
 	// test invalid server
 	s := &Server{}
-	want := false
-	got := s.Check(&net.Dialer{}, time.Second)
-	if got != want {
-		t.Errorf("got %t, want %t", got, want)
+	got := s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if got.Trusted || got.Error == nil {
+		t.Errorf("got %v, want untrusted with error", got)
 	}
 
 	// test invalid hash
 	s = &Server{
-		URL:  ts.URL,
-		Hash: "",
+		URL:    ts.URL,
+		Hashes: []string{""},
 	}
-	want = false
-	got = s.Check(&net.Dialer{}, time.Second)
-	if got != want {
-		t.Errorf("got %t, want %t", got, want)
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if got.Trusted || !errors.Is(got.Error, ErrHashMismatch) ||
+		got.Reason != ReasonHashMismatch {
+		t.Errorf("got %v, want untrusted with hash mismatch", got)
+	}
+	if got.Fingerprint != hash {
+		t.Errorf("got %s, want %s", got.Fingerprint, hash)
 	}
 
 	// test valid hash
-	cert := ts.Certificate()
-	sha := sha256.Sum256(cert.Raw)
-	hash := hex.EncodeToString(sha[:])
 	s = &Server{
-		URL:  ts.URL,
-		Hash: hash,
+		URL:    ts.URL,
+		Hashes: []string{hash},
+	}
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if !got.Trusted || got.Error != nil || got.Reason != ReasonNone {
+		t.Errorf("got %v, want trusted without error", got)
+	}
+	if got.URL != ts.URL {
+		t.Errorf("got %s, want %s", got.URL, ts.URL)
+	}
+	if got.Fingerprint != hash {
+		t.Errorf("got %s, want %s", got.Fingerprint, hash)
+	}
+	if got.Latency <= 0 {
+		t.Errorf("got %v, want latency > 0", got.Latency)
+	}
+
+	// test valid public key pin
+	pin := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	s = &Server{
+		URL:    ts.URL,
+		Hashes: []string{PinPrefix + base64.StdEncoding.EncodeToString(pin[:])},
+	}
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if !got.Trusted || got.Fingerprint != s.Hashes[0] {
+		t.Errorf("got %v, want trusted with fingerprint %s", got, s.Hashes[0])
+	}
+
+	// test multiple hashes
+	s = &Server{
+		URL:    ts.URL,
+		Hashes: []string{"invalid", hash},
 	}
-	want = true
-	got = s.Check(&net.Dialer{}, time.Second)
-	if got != want {
-		t.Errorf("got %t, want %t", got, want)
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if !got.Trusted || got.Fingerprint != hash {
+		t.Errorf("got %v, want trusted with fingerprint %s", got, hash)
+	}
+
+	// test no tls
+	hs := httptest.NewServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer hs.Close()
+
+	s = &Server{
+		URL:    hs.URL,
+		Hashes: []string{hash},
+	}
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if got.Trusted || !errors.Is(got.Error, ErrNoTLS) ||
+		got.Reason != ReasonNoTLS {
+		t.Errorf("got %v, want untrusted with no tls error", got)
+	}
+
+	// test tls handshake error
+	s = &Server{
+		URL:    "https" + strings.TrimPrefix(hs.URL, "http"),
+		Hashes: []string{hash},
+	}
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if got.Trusted || got.Reason != ReasonTLSHandshake {
+		t.Errorf("got %v, want untrusted with tls handshake error", got)
+	}
+
+	// test timeout
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	go func() {
+		// accept connections but never respond
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer func() { _ = c.Close() }()
+		}
+	}()
+
+	s = &Server{
+		URL:    "https://" + l.Addr().String(),
+		Hashes: []string{hash},
+	}
+	got = s.Check(context.Background(), &net.Dialer{}, 100*time.Millisecond, logging.Default())
+	if got.Trusted || got.Reason != ReasonTimeout {
+		t.Errorf("got %v, want untrusted with timeout", got)
+	}
+
+	// test connection refused
+	r, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := r.Addr().String()
+	_ = r.Close()
+
+	s = &Server{
+		URL:    "https://" + addr,
+		Hashes: []string{hash},
+	}
+	got = s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+	if got.Trusted || got.Reason != ReasonRefused {
+		t.Errorf("got %v, want untrusted with connection refused", got)
 	}
 }
 
 // TestNewServer tests NewServer.
 func TestNewServer(t *testing.T) {
 	url := "test.example.com"
-	hash := "test-hash"
-	s := NewServer(url, hash)
-	if s == nil ||
+	sha := sha256.Sum256([]byte("test"))
+	hash := hex.EncodeToString(sha[:])
+	pin := PinPrefix + base64.StdEncoding.EncodeToString(sha[:])
+	s, err := NewServer(url, hash)
+	if err != nil ||
 		s.URL != url ||
-		s.Hash != hash {
+		!reflect.DeepEqual(s.Hashes, []string{hash}) {
 		t.Errorf("invalid server")
 	}
+
+	// test hash normalization and multiple hashes
+	s, err = NewServer(url, strings.ToUpper(hash), pin)
+	want := []string{hash, pin}
+	if err != nil || !reflect.DeepEqual(s.Hashes, want) {
+		t.Errorf("got %v, %v, want %v", s.Hashes, err, want)
+	}
+
+	// test invalid hash
+	if _, err := NewServer(url, hash, "test-hash"); !errors.Is(err, ErrInvalidHash) {
+		t.Errorf("invalid hash should return error")
+	}
+}
+
+// TestServerCheckContext tests Check of Server with a canceled context.
+func TestServerCheckContext(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	cert := ts.Certificate()
+	sha := sha256.Sum256(cert.Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	// test canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := &Server{
+		URL:    ts.URL,
+		Hashes: []string{hash},
+	}
+	got := s.Check(ctx, &net.Dialer{}, time.Second, logging.Default())
+	if got.Trusted || !errors.Is(got.Error, context.Canceled) {
+		t.Errorf("got %v, want untrusted with canceled context", got)
+	}
+}
+
+// TestServerCheckTrace tests Check of Server with a httptrace.ClientTrace.
+func TestServerCheckTrace(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	// test trace hooks
+	events := []string{}
+	ctx := httptrace.WithClientTrace(context.Background(), &httptrace.ClientTrace{
+		ConnectDone: func(string, string, error) {
+			events = append(events, "connect")
+		},
+		TLSHandshakeStart: func() {
+			events = append(events, "tls start")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			if err != nil || !state.HandshakeComplete {
+				t.Errorf("invalid tls handshake: %v", err)
+			}
+			events = append(events, "tls done")
+		},
+		GotFirstResponseByte: func() {
+			events = append(events, "response")
+		},
+	})
+	s := &Server{URL: ts.URL}
+	s.Check(ctx, &net.Dialer{}, time.Second, logging.Default())
+	want := []string{"connect", "tls start", "tls done", "response"}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got %v, want %v", events, want)
+	}
+}
+
+// TestServerCheckChain tests Check of Server with chain matching, CA bundles
+// and hostname verification.
+func TestServerCheckChain(t *testing.T) {
+	pki := newTestPKI(t)
+	other := newTestPKI(t)
+	ts := pki.newServer()
+	defer ts.Close()
+	forged := pki.forgedCert(t)
+	fs := newTLSServer(forged)
+	defer fs.Close()
+	expired := pki.expiredCert(t)
+	es := newTLSServer(expired)
+	defer es.Close()
+
+	leafHash := certFingerprint(pki.leaf)
+	forgedHash := certFingerprint(forged.Leaf)
+	expiredHash := certFingerprint(expired.Leaf)
+	intermediateHash := certFingerprint(pki.intermediate)
+	rootHash := certFingerprint(pki.root)
+	localhost := strings.Replace(ts.URL, "127.0.0.1", "localhost", 1)
+
+	for i, test := range []struct {
+		url            string
+		hash           string
+		matchChain     bool
+		caBundle       []byte
+		verifyHostname bool
+		reason         Reason
+		fingerprint    string
+	}{
+		// leaf only
+		{ts.URL, leafHash, false, nil, false, ReasonNone, leafHash},
+		{ts.URL, intermediateHash, false, nil, false, ReasonHashMismatch, leafHash},
+
+		// presented chain
+		{ts.URL, intermediateHash, true, nil, false, ReasonNone, intermediateHash},
+		{ts.URL, rootHash, true, nil, false, ReasonHashMismatch, leafHash},
+		{fs.URL, intermediateHash, true, nil, false, ReasonHashMismatch, forgedHash},
+		{fs.URL, forgedHash, true, nil, false, ReasonNone, forgedHash},
+		{ts.URL, intermediateHash, true, []byte{}, false, ReasonNone, intermediateHash},
+		{es.URL, intermediateHash, true, nil, false, ReasonNone, intermediateHash},
+
+		// verified chain
+		{ts.URL, rootHash, true, pki.rootPEM, false, ReasonNone, rootHash},
+		{ts.URL, rootHash, false, pki.rootPEM, false, ReasonHashMismatch, leafHash},
+		{ts.URL, leafHash, false, pki.rootPEM, false, ReasonNone, leafHash},
+		{ts.URL, rootHash, true, other.rootPEM, false, ReasonVerify, leafHash},
+		{ts.URL, rootHash, true, []byte("invalid"), false, ReasonVerify, leafHash},
+		{es.URL, rootHash, true, pki.rootPEM, false, ReasonVerify, expiredHash},
+
+		// hostname verification
+		{ts.URL, leafHash, false, nil, true, ReasonNone, leafHash},
+		{ts.URL, rootHash, true, pki.rootPEM, true, ReasonNone, rootHash},
+		{localhost, leafHash, false, nil, true, ReasonVerify, leafHash},
+		{localhost, rootHash, true, pki.rootPEM, true, ReasonVerify, leafHash},
+	} {
+		s, err := NewServer(test.url, test.hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.MatchChain = test.matchChain
+		s.CABundle = test.caBundle
+		s.VerifyHostname = test.verifyHostname
+
+		got := s.Check(context.Background(), &net.Dialer{}, time.Second, logging.Default())
+		if got.Reason != test.reason {
+			t.Errorf("%d: got %s (%v), want %s", i, got.Reason, got.Error, test.reason)
+		}
+		if got.Trusted != (test.reason == ReasonNone) {
+			t.Errorf("%d: got %t, want %t", i, got.Trusted, !got.Trusted)
+		}
+		if got.Fingerprint != test.fingerprint {
+			t.Errorf("%d: got %s, want %s", i, got.Fingerprint, test.fingerprint)
+		}
+	}
 }
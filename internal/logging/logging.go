@@ -0,0 +1,128 @@
+// Package logging contains the structured logger of the TND.
+package logging
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Field names used by the TND in log messages.
+const (
+	FieldError   = "error"
+	FieldURL     = "url"
+	FieldReason  = "reason"
+	FieldTrigger = "trigger"
+	FieldTrusted = "trusted"
+	FieldServer  = "server"
+	FieldFile    = "file"
+	FieldFolder  = "folder"
+	FieldOp      = "op"
+	FieldDst     = "dst"
+	FieldGot     = "got"
+	FieldWant    = "want"
+	FieldEvents  = "events"
+)
+
+// Logger is a structured logger. The args of the methods are alternating
+// field names and values as in log/slog, e.g.,
+// Debug("message", FieldURL, url, FieldError, err).
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+}
+
+// badKey is the field name of a value without field name, as in log/slog.
+const badKey = "!BADKEY"
+
+// logrusLogger is a Logger that logs with logrus.
+type logrusLogger struct {
+	l logrus.FieldLogger
+}
+
+// entry returns the logrus entry with the fields in args.
+func (l *logrusLogger) entry(args []any) logrus.FieldLogger {
+	if len(args) == 0 {
+		return l.l
+	}
+	fields := make(logrus.Fields, (len(args)+1)/2)
+	for len(args) > 0 {
+		key, ok := args[0].(string)
+		if !ok || len(args) == 1 {
+			fields[badKey] = args[0]
+			args = args[1:]
+			continue
+		}
+		fields[key] = args[1]
+		args = args[2:]
+	}
+	return l.l.WithFields(fields)
+}
+
+// Debug logs msg with args on debug level.
+func (l *logrusLogger) Debug(msg string, args ...any) {
+	l.entry(args).Debug(msg)
+}
+
+// Info logs msg with args on info level.
+func (l *logrusLogger) Info(msg string, args ...any) {
+	l.entry(args).Info(msg)
+}
+
+// Warn logs msg with args on warning level.
+func (l *logrusLogger) Warn(msg string, args ...any) {
+	l.entry(args).Warn(msg)
+}
+
+// Error logs msg with args on error level.
+func (l *logrusLogger) Error(msg string, args ...any) {
+	l.entry(args).Error(msg)
+}
+
+// NewLogrus returns a new Logger that logs with the logrus logger l; nil
+// uses the standard logrus logger.
+func NewLogrus(l logrus.FieldLogger) Logger {
+	if l == nil {
+		l = logrus.StandardLogger()
+	}
+	return &logrusLogger{l: l}
+}
+
+// slogLogger is a Logger that logs with a slog.Handler.
+type slogLogger struct {
+	l *slog.Logger
+}
+
+// Debug logs msg with args on debug level.
+func (l *slogLogger) Debug(msg string, args ...any) {
+	l.l.Log(context.Background(), slog.LevelDebug, msg, args...)
+}
+
+// Info logs msg with args on info level.
+func (l *slogLogger) Info(msg string, args ...any) {
+	l.l.Log(context.Background(), slog.LevelInfo, msg, args...)
+}
+
+// Warn logs msg with args on warning level.
+func (l *slogLogger) Warn(msg string, args ...any) {
+	l.l.Log(context.Background(), slog.LevelWarn, msg, args...)
+}
+
+// Error logs msg with args on error level.
+func (l *slogLogger) Error(msg string, args ...any) {
+	l.l.Log(context.Background(), slog.LevelError, msg, args...)
+}
+
+// NewSlog returns a new Logger that logs with the slog.Handler h.
+func NewSlog(h slog.Handler) Logger {
+	return &slogLogger{l: slog.New(h)}
+}
+
+// Default returns the default Logger that logs with the standard logrus
+// logger.
+func Default() Logger {
+	return NewLogrus(nil)
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+// TestNewLogrus tests NewLogrus.
+func TestNewLogrus(t *testing.T) {
+	b := &bytes.Buffer{}
+	l := logrus.New()
+	l.SetOutput(b)
+	l.SetFormatter(&logrus.JSONFormatter{})
+	l.SetLevel(logrus.DebugLevel)
+	logger := NewLogrus(l)
+
+	for level, log := range map[string]func(string, ...any){
+		"debug":   logger.Debug,
+		"info":    logger.Info,
+		"warning": logger.Warn,
+		"error":   logger.Error,
+	} {
+		b.Reset()
+		log("test message", FieldURL, "https://test.example.com",
+			FieldError, errors.New("test error"), "missing")
+
+		got := map[string]any{}
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		for key, want := range map[string]any{
+			"level":    level,
+			"msg":      "test message",
+			FieldURL:   "https://test.example.com",
+			FieldError: "test error",
+			badKey:     "missing",
+		} {
+			if got[key] != want {
+				t.Errorf("%s: got %v, want %v", key, got[key], want)
+			}
+		}
+	}
+
+	// test without fields
+	b.Reset()
+	logger.Info("test message")
+	if !bytes.Contains(b.Bytes(), []byte(`"msg":"test message"`)) {
+		t.Errorf("invalid log message: %s", b)
+	}
+
+	// test standard logger
+	if NewLogrus(nil).(*logrusLogger).l != logrus.StandardLogger() {
+		t.Error("nil should use standard logger")
+	}
+	if Default().(*logrusLogger).l != logrus.StandardLogger() {
+		t.Error("default should use standard logger")
+	}
+}
+
+// TestNewSlog tests NewSlog.
+func TestNewSlog(t *testing.T) {
+	b := &bytes.Buffer{}
+	logger := NewSlog(slog.NewJSONHandler(b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	}))
+
+	for level, log := range map[string]func(string, ...any){
+		"DEBUG": logger.Debug,
+		"INFO":  logger.Info,
+		"WARN":  logger.Warn,
+		"ERROR": logger.Error,
+	} {
+		b.Reset()
+		log("test message", FieldURL, "https://test.example.com")
+
+		got := map[string]any{}
+		if err := json.Unmarshal(b.Bytes(), &got); err != nil {
+			t.Fatal(err)
+		}
+		if got["level"] != level || got["msg"] != "test message" ||
+			got[FieldURL] != "https://test.example.com" {
+			t.Errorf("invalid log message: %v", got)
+		}
+	}
+}
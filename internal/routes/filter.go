@@ -0,0 +1,94 @@
+package routes
+
+import (
+	"net"
+	"slices"
+
+	"github.com/vishvananda/netlink"
+)
+
+// Filter selects the route update events that trigger probes. Empty fields
+// do not filter, so the zero Filter matches all events.
+type Filter struct {
+	// Tables are the routing tables, e.g., unix.RT_TABLE_MAIN
+	Tables []int
+
+	// Protocols are the route protocols, e.g., unix.RTPROT_DHCP
+	Protocols []int
+
+	// IgnoreInterfaces are the names of the interfaces whose routes are
+	// ignored, e.g., the tunnel device of a VPN client
+	IgnoreInterfaces []string
+
+	// DefaultOnly only matches default routes
+	DefaultOnly bool
+
+	// Families are the address families, e.g., unix.AF_INET
+	Families []int
+}
+
+// interfaceName returns the name of the interface with index, for testing.
+var interfaceName = func(index int) string {
+	iface, err := net.InterfaceByIndex(index)
+	if err != nil {
+		return ""
+	}
+	return iface.Name
+}
+
+// isDefault returns whether r is a default route.
+func isDefault(r *netlink.Route) bool {
+	if r.Dst == nil {
+		return true
+	}
+	ones, _ := r.Dst.Mask.Size()
+	return ones == 0
+}
+
+// ignoredInterface returns whether all interfaces of r are ignored.
+func (f *Filter) ignoredInterface(r *netlink.Route) bool {
+	if len(f.IgnoreInterfaces) == 0 {
+		return false
+	}
+
+	// get interfaces of route and its next hops
+	indexes := []int{}
+	if r.LinkIndex > 0 {
+		indexes = append(indexes, r.LinkIndex)
+	}
+	for _, hop := range r.MultiPath {
+		if hop.LinkIndex > 0 {
+			indexes = append(indexes, hop.LinkIndex)
+		}
+	}
+	if len(indexes) == 0 {
+		return false
+	}
+
+	for _, index := range indexes {
+		if !slices.Contains(f.IgnoreInterfaces, interfaceName(index)) {
+			return false
+		}
+	}
+	return true
+}
+
+// Match returns whether the route r passes the filter. A nil Filter matches
+// all routes.
+func (f *Filter) Match(r *netlink.Route) bool {
+	switch {
+	case f == nil:
+		return true
+	case len(f.Tables) > 0 && !slices.Contains(f.Tables, r.Table):
+		return false
+	case len(f.Protocols) > 0 && !slices.Contains(f.Protocols, int(r.Protocol)):
+		return false
+	case len(f.Families) > 0 && !slices.Contains(f.Families, r.Family):
+		return false
+	case f.DefaultOnly && !isDefault(r):
+		return false
+	case f.ignoredInterface(r):
+		return false
+	}
+	return true
+}
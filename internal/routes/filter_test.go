@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"net"
+	"testing"
+
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+// TestFilterMatch tests Match of Filter.
+func TestFilterMatch(t *testing.T) {
+	defer func(f func(int) string) { interfaceName = f }(interfaceName)
+	interfaceName = func(index int) string {
+		return map[int]string{1: "eth0", 2: "tun0", 3: "tun1"}[index]
+	}
+
+	_, dst, _ := net.ParseCIDR("192.168.1.0/24")
+	_, defaultDst, _ := net.ParseCIDR("0.0.0.0/0")
+	route := &netlink.Route{
+		LinkIndex: 1,
+		Dst:       dst,
+		Protocol:  unix.RTPROT_DHCP,
+		Family:    unix.AF_INET,
+		Table:     unix.RT_TABLE_MAIN,
+	}
+	withRoute := func(f func(r *netlink.Route)) *netlink.Route {
+		r := *route
+		f(&r)
+		return &r
+	}
+
+	// test nil and empty filter
+	var nilFilter *Filter
+	if !nilFilter.Match(route) || !(&Filter{}).Match(route) {
+		t.Error("nil and empty filter should match")
+	}
+
+	// test matching
+	for i, test := range []struct {
+		filter *Filter
+		route  *netlink.Route
+	}{
+		{&Filter{Tables: []int{unix.RT_TABLE_MAIN}}, route},
+		{&Filter{Protocols: []int{unix.RTPROT_BOOT, unix.RTPROT_DHCP}}, route},
+		{&Filter{Families: []int{unix.AF_INET}}, route},
+		{&Filter{IgnoreInterfaces: []string{"tun0"}}, route},
+		{&Filter{DefaultOnly: true}, withRoute(func(r *netlink.Route) {
+			r.Dst = nil
+		})},
+		{&Filter{DefaultOnly: true}, withRoute(func(r *netlink.Route) {
+			r.Dst = defaultDst
+		})},
+		{&Filter{IgnoreInterfaces: []string{"tun0"}}, withRoute(func(r *netlink.Route) {
+			r.LinkIndex = 0
+			r.MultiPath = []*netlink.NexthopInfo{{LinkIndex: 1}, {LinkIndex: 2}}
+		})},
+		{&Filter{IgnoreInterfaces: []string{"tun0"}}, withRoute(func(r *netlink.Route) {
+			r.LinkIndex = 0
+		})},
+	} {
+		if !test.filter.Match(test.route) {
+			t.Errorf("%d: filter should match", i)
+		}
+	}
+
+	// test not matching
+	for i, test := range []struct {
+		filter *Filter
+		route  *netlink.Route
+	}{
+		{&Filter{Tables: []int{unix.RT_TABLE_MAIN}}, withRoute(func(r *netlink.Route) {
+			r.Table = 1000
+		})},
+		{&Filter{Protocols: []int{unix.RTPROT_BOOT}}, route},
+		{&Filter{Families: []int{unix.AF_INET6}}, route},
+		{&Filter{IgnoreInterfaces: []string{"eth0"}}, route},
+		{&Filter{DefaultOnly: true}, route},
+		{&Filter{IgnoreInterfaces: []string{"tun0", "tun1"}}, withRoute(func(r *netlink.Route) {
+			r.LinkIndex = 0
+			r.MultiPath = []*netlink.NexthopInfo{{LinkIndex: 2}, {LinkIndex: 3}}
+		})},
+	} {
+		if test.filter.Match(test.route) {
+			t.Errorf("%d: filter should not match", i)
+		}
+	}
+}
@@ -2,7 +2,9 @@
 package routes
 
 import (
-	log "github.com/sirupsen/logrus"
+	"sync/atomic"
+
+	"github.com/telekom-mms/tnd/internal/logging"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 )
@@ -11,6 +13,7 @@ import (
 type Watcher interface {
 	Start() error
 	Stop()
+	SetFilter(filter *Filter)
 }
 
 // Watch waits for routing update events and then probes the
@@ -19,6 +22,8 @@ type Watch struct {
 	events chan netlink.RouteUpdate
 	probes chan struct{}
 	done   chan struct{}
+	logger logging.Logger
+	filter atomic.Pointer[Filter]
 }
 
 // sendProbe sends a probe request over the probe channel.
@@ -38,14 +43,25 @@ func (w *Watch) start() {
 	for e := range w.events {
 		switch e.Type {
 		case unix.RTM_NEWROUTE:
-			log.WithField("dst", e.Dst).Debug("TND got route NEW event")
+			w.logger.Debug("TND got route NEW event", logging.FieldDst, e.Dst)
 		case unix.RTM_DELROUTE:
-			log.WithField("dst", e.Dst).Debug("TND got route DEL event")
+			w.logger.Debug("TND got route DEL event", logging.FieldDst, e.Dst)
+		}
+		if !w.filter.Load().Match(&e.Route) {
+			w.logger.Debug("TND ignoring filtered route event",
+				logging.FieldDst, e.Dst)
+			continue
 		}
 		w.sendProbe()
 	}
 }
 
+// SetFilter sets the filter of the route update events that trigger probes;
+// nil disables filtering. It can be called while the Watch is running.
+func (w *Watch) SetFilter(filter *Filter) {
+	w.filter.Store(filter)
+}
+
 // netlinkRouteSubscribe is netlink.RouteSubscribe for testing.
 var netlinkRouteSubscribe = netlink.RouteSubscribe
 
@@ -53,7 +69,7 @@ var netlinkRouteSubscribe = netlink.RouteSubscribe
 func (w *Watch) Start() error {
 	// register for route update events
 	if err := netlinkRouteSubscribe(w.events, w.done); err != nil {
-		log.WithError(err).Error("TND route subscribe error")
+		w.logger.Error("TND route subscribe error", logging.FieldError, err)
 		return err
 	}
 
@@ -71,11 +87,12 @@ func (w *Watch) Stop() {
 	close(w.done)
 }
 
-// NewWatch returns a new Watch.
-func NewWatch(probes chan struct{}) *Watch {
+// NewWatch returns a new Watch that logs with logger.
+func NewWatch(probes chan struct{}, logger logging.Logger) *Watch {
 	return &Watch{
 		events: make(chan netlink.RouteUpdate),
 		probes: probes,
 		done:   make(chan struct{}),
+		logger: logger,
 	}
 }
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"testing"
 
+	"github.com/telekom-mms/tnd/internal/logging"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
 )
@@ -12,7 +13,7 @@ import (
 func TestWatchStartEvents(_ *testing.T) {
 	// create and start watch
 	probes := make(chan struct{})
-	rw := NewWatch(probes)
+	rw := NewWatch(probes, logging.Default())
 	go rw.start()
 	<-probes
 
@@ -24,6 +25,14 @@ func TestWatchStartEvents(_ *testing.T) {
 	rw.events <- netlink.RouteUpdate{Type: unix.RTM_DELROUTE}
 	<-probes
 
+	// filtered route event, followed by matching event
+	rw.SetFilter(&Filter{Tables: []int{unix.RT_TABLE_MAIN}})
+	rw.events <- netlink.RouteUpdate{Type: unix.RTM_NEWROUTE,
+		Route: netlink.Route{Table: 1000}}
+	rw.events <- netlink.RouteUpdate{Type: unix.RTM_NEWROUTE,
+		Route: netlink.Route{Table: unix.RT_TABLE_MAIN}}
+	<-probes
+
 	close(rw.done)
 }
 
@@ -37,14 +46,14 @@ func TestWatchStartStop(t *testing.T) {
 			return errors.New("test error")
 		}
 
-		rw := NewWatch(probes)
+		rw := NewWatch(probes, logging.Default())
 		if err := rw.Start(); err == nil {
 			t.Error("start should fail")
 		}
 	})
 
 	t.Run("no errors", func(t *testing.T) {
-		rw := NewWatch(probes)
+		rw := NewWatch(probes, logging.Default())
 		if err := rw.Start(); err != nil {
 			t.Errorf("start should not fail: %v", err)
 		}
@@ -55,7 +64,7 @@ func TestWatchStartStop(t *testing.T) {
 // TestNewWatch tests NewWatch.
 func TestNewWatch(t *testing.T) {
 	probes := make(chan struct{})
-	rw := NewWatch(probes)
+	rw := NewWatch(probes, logging.Default())
 	if rw.events == nil {
 		t.Errorf("got nil, want != nil")
 	}
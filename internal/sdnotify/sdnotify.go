@@ -0,0 +1,58 @@
+// Package sdnotify contains components for systemd service notifications.
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// Service states.
+const (
+	Ready    = "READY=1"
+	Stopping = "STOPPING=1"
+)
+
+// Reloading returns the reloading state with the current CLOCK_MONOTONIC
+// time in microseconds, which systemd requires for Type=notify-reload.
+func Reloading() string {
+	var ts unix.Timespec
+	_ = unix.ClockGettime(unix.CLOCK_MONOTONIC, &ts)
+	return fmt.Sprintf("RELOADING=1\nMONOTONIC_USEC=%d", ts.Nano()/1000)
+}
+
+// Status returns the service status state with the status text s.
+func Status(s string) string {
+	return "STATUS=" + s
+}
+
+// Notify sends state to the service manager over the socket in the
+// NOTIFY_SOCKET environment variable. It returns false if NOTIFY_SOCKET is
+// not set, e.g., when the service is not started by systemd.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// handle abstract socket
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{
+		Name: socket,
+		Net:  "unixgram",
+	})
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,70 @@
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+// TestStatus tests Status.
+func TestStatus(t *testing.T) {
+	want := "STATUS=test status"
+	got := Status("test status")
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// TestReloading tests Reloading.
+func TestReloading(t *testing.T) {
+	var usec int64
+	if _, err := fmt.Sscanf(Reloading(), "RELOADING=1\nMONOTONIC_USEC=%d", &usec); err != nil ||
+		usec <= 0 {
+		t.Errorf("invalid reloading state %q: %v", Reloading(), err)
+	}
+}
+
+// TestNotify tests Notify.
+func TestNotify(t *testing.T) {
+	// test without socket
+	t.Setenv("NOTIFY_SOCKET", "")
+	if ok, err := Notify(Ready); ok || err != nil {
+		t.Errorf("got %t, %v, want false without error", ok, err)
+	}
+
+	// test with socket
+	for _, name := range []string{
+		filepath.Join(t.TempDir(), "notify.sock"),
+		"@tnd-sdnotify-test",
+	} {
+		addr := &net.UnixAddr{Name: name, Net: "unixgram"}
+		if name[0] == '@' {
+			addr.Name = "\x00" + name[1:]
+		}
+		conn, err := net.ListenUnixgram("unixgram", addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Setenv("NOTIFY_SOCKET", name)
+		if ok, err := Notify(Ready); !ok || err != nil {
+			t.Errorf("%s: got %t, %v, want true without error", name, ok, err)
+		}
+		b := make([]byte, 64)
+		n, err := conn.Read(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(b[:n]) != Ready {
+			t.Errorf("%s: got %s, want %s", name, b[:n], Ready)
+		}
+		_ = conn.Close()
+	}
+
+	// test invalid socket
+	t.Setenv("NOTIFY_SOCKET", filepath.Join(t.TempDir(), "missing.sock"))
+	if ok, err := Notify(Ready); ok || err == nil {
+		t.Errorf("got %t, %v, want false with error", ok, err)
+	}
+}
@@ -1,6 +1,13 @@
 package tnd
 
-import "time"
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/telekom-mms/tnd/internal/routes"
+	"golang.org/x/sys/unix"
+)
 
 var (
 	// WatchFiles are the default files to watch for changes. They are
@@ -24,15 +31,102 @@ var (
 	// TrustedTimer is the default timer for periodic checks in case of a
 	// trusted network.
 	TrustedTimer = 60 * time.Second
+
+	// ProbeTimeout is the default overall timeout of parallel probes.
+	ProbeTimeout = 10 * time.Second
+
+	// DebounceQuiet is the default quiet period of route and file events
+	// before a probe; 0 disables debouncing by default.
+	DebounceQuiet time.Duration = 0
+
+	// DebounceMaxDelay is the default maximum delay of a probe after the
+	// first route or file event.
+	DebounceMaxDelay = 2 * time.Second
+)
+
+// ValidationError is the error when a field of a Config or Server or an
+// environment variable is invalid.
+type ValidationError struct {
+	// Field is the name of the invalid field, e.g., "HTTPSTimeout" or
+	// "Servers[1].Hashes[0]", or of the environment variable, e.g.,
+	// "TND_HTTPS_TIMEOUT".
+	Field string
+
+	// Err is the reason why the field is invalid.
+	Err error
+}
+
+// Error returns the error as string.
+func (e *ValidationError) Error() string {
+	return "invalid " + e.Field + ": " + e.Err.Error()
+}
+
+// Unwrap returns the reason why the field is invalid.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// TrustPolicy is the policy that determines how many trusted servers must be
+// trusted for the network to be trusted.
+type TrustPolicy int
+
+// Trust policies.
+const (
+	// TrustPolicyAny requires any one of the trusted servers.
+	TrustPolicyAny TrustPolicy = iota
+
+	// TrustPolicyAll requires all of the trusted servers.
+	TrustPolicyAll
+
+	// TrustPolicyQuorum requires TrustQuorum of the trusted servers.
+	TrustPolicyQuorum
 )
 
+// String returns the trust policy as string.
+func (p TrustPolicy) String() string {
+	switch p {
+	case TrustPolicyAny:
+		return "any"
+	case TrustPolicyAll:
+		return "all"
+	case TrustPolicyQuorum:
+		return "quorum"
+	}
+	return "unknown"
+}
+
+// MarshalText returns the trust policy as text.
+func (p TrustPolicy) MarshalText() ([]byte, error) {
+	if p < TrustPolicyAny || p > TrustPolicyQuorum {
+		return nil, fmt.Errorf("unknown trust policy %d", p)
+	}
+	return []byte(p.String()), nil
+}
+
+// UnmarshalText parses the trust policy from text.
+func (p *TrustPolicy) UnmarshalText(text []byte) error {
+	for _, policy := range []TrustPolicy{
+		TrustPolicyAny,
+		TrustPolicyAll,
+		TrustPolicyQuorum,
+	} {
+		if string(text) == policy.String() {
+			*p = policy
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown trust policy %q", text)
+}
+
 // Config is a TND configuration.
 type Config struct {
 	// WatchFiles are the files to watch for changes. By default, they are
 	// resolv.conf files in /etc and /run/systemd/resolve.
 	WatchFiles []string
 
-	// WaitCheck is the wait time before http checks.
+	// WaitCheck is the wait time before http checks. With sequential
+	// probes, it is waited before each check unless debouncing is
+	// enabled, then only once before all checks.
 	WaitCheck time.Duration
 
 	// HTTPSTimeout is the timeout for http requests.
@@ -45,37 +139,195 @@ type Config struct {
 	// TrustedTimer is the timer for periodic checks in case of a
 	// trusted network.
 	TrustedTimer time.Duration
+
+	// TrustPolicy is the policy that determines how many trusted
+	// servers are required for a trusted network. By default, any one
+	// trusted server is enough.
+	TrustPolicy TrustPolicy
+
+	// TrustQuorum is the number of trusted servers required for a
+	// trusted network with TrustPolicyQuorum.
+	TrustQuorum int
+
+	// ParallelProbes enables checking all trusted servers concurrently
+	// instead of sequentially. WaitCheck is then only waited once
+	// before all checks.
+	ParallelProbes bool
+
+	// ProbeTimeout is the overall timeout of parallel probes; 0 means
+	// no overall timeout.
+	ProbeTimeout time.Duration
+
+	// DebounceQuiet is the quiet period of route and file events: a
+	// burst of events triggers a single probe once no new event arrived
+	// for DebounceQuiet; 0, the default, disables debouncing. With
+	// debouncing, WaitCheck is only waited once before all checks.
+	DebounceQuiet time.Duration
+
+	// DebounceMaxDelay is the maximum delay of a probe after the first
+	// route or file event of a burst, even if events keep arriving.
+	DebounceMaxDelay time.Duration
+
+	// RouteTables are the routing tables whose route changes trigger
+	// probes, e.g., 254 for the main table; empty means all tables.
+	RouteTables []int
+
+	// RouteProtocols are the route protocols whose route changes
+	// trigger probes, e.g., 3 for boot or 16 for dhcp; empty means all
+	// protocols.
+	RouteProtocols []int
+
+	// RouteIgnoreInterfaces are the interfaces whose route changes do
+	// not trigger probes, e.g., the tunnel device of a VPN client.
+	RouteIgnoreInterfaces []string
+
+	// RouteDefaultOnly restricts the route changes that trigger probes
+	// to default routes.
+	RouteDefaultOnly bool
+
+	// RouteFamilies are the address families whose route changes
+	// trigger probes, 4 for IPv4 and 6 for IPv6; empty means both.
+	RouteFamilies []int
 }
 
 // Copy returns a copy of Config.
 func (c *Config) Copy() *Config {
 	tnd := *c
 	tnd.WatchFiles = append(c.WatchFiles[:0:0], c.WatchFiles...)
+	tnd.RouteTables = append(c.RouteTables[:0:0], c.RouteTables...)
+	tnd.RouteProtocols = append(c.RouteProtocols[:0:0], c.RouteProtocols...)
+	tnd.RouteIgnoreInterfaces = append(c.RouteIgnoreInterfaces[:0:0],
+		c.RouteIgnoreInterfaces...)
+	tnd.RouteFamilies = append(c.RouteFamilies[:0:0], c.RouteFamilies...)
 
 	return &tnd
 }
 
+// Validate checks Config and returns a ValidationError for the first invalid
+// field, if any.
+func (c *Config) Validate() error {
+	invalid := func(field, format string, a ...any) error {
+		return &ValidationError{
+			Field: field,
+			Err:   fmt.Errorf(format, a...),
+		}
+	}
+
+	switch {
+	case c == nil:
+		return invalid("Config", "config is nil")
+	case len(c.WatchFiles) == 0:
+		return invalid("WatchFiles", "no files to watch")
+	case c.WaitCheck < 0:
+		return invalid("WaitCheck", "%v is negative", c.WaitCheck)
+	case c.HTTPSTimeout <= 0:
+		return invalid("HTTPSTimeout", "%v is not positive", c.HTTPSTimeout)
+	case c.UntrustedTimer <= 0:
+		return invalid("UntrustedTimer", "%v is not positive", c.UntrustedTimer)
+	case c.TrustedTimer <= 0:
+		return invalid("TrustedTimer", "%v is not positive", c.TrustedTimer)
+	case c.ProbeTimeout < 0:
+		return invalid("ProbeTimeout", "%v is negative", c.ProbeTimeout)
+	case c.DebounceQuiet < 0:
+		return invalid("DebounceQuiet", "%v is negative", c.DebounceQuiet)
+	case c.DebounceMaxDelay < c.DebounceQuiet:
+		return invalid("DebounceMaxDelay", "%v is less than quiet period %v",
+			c.DebounceMaxDelay, c.DebounceQuiet)
+	case c.TrustPolicy < TrustPolicyAny || c.TrustPolicy > TrustPolicyQuorum:
+		return invalid("TrustPolicy", "unknown trust policy %d", c.TrustPolicy)
+	case c.TrustPolicy == TrustPolicyQuorum && c.TrustQuorum < 1:
+		return invalid("TrustQuorum", "quorum %d is less than 1", c.TrustQuorum)
+	}
+	for i, t := range c.RouteTables {
+		if t < 0 || int64(t) > math.MaxUint32 {
+			return invalid(fmt.Sprintf("RouteTables[%d]", i),
+				"invalid routing table %d", t)
+		}
+	}
+	for i, p := range c.RouteProtocols {
+		if p < 0 || p > 255 {
+			return invalid(fmt.Sprintf("RouteProtocols[%d]", i),
+				"invalid route protocol %d", p)
+		}
+	}
+	for i, iface := range c.RouteIgnoreInterfaces {
+		if iface == "" {
+			return invalid(fmt.Sprintf("RouteIgnoreInterfaces[%d]", i),
+				"empty interface name")
+		}
+	}
+	for i, f := range c.RouteFamilies {
+		if f != 4 && f != 6 {
+			return invalid(fmt.Sprintf("RouteFamilies[%d]", i),
+				"invalid address family %d", f)
+		}
+	}
+	return nil
+}
+
 // Valid returns whether Config is valid.
 func (c *Config) Valid() bool {
-	if c == nil ||
-		len(c.WatchFiles) == 0 ||
-		c.WaitCheck < 0 ||
-		c.HTTPSTimeout < 0 ||
-		c.UntrustedTimer < 0 ||
-		c.TrustedTimer < 0 {
-		// invalid
-		return false
+	return c.Validate() == nil
+}
+
+// requiredServers returns the number of trusted servers out of n servers
+// that are required for a trusted network.
+func (c *Config) requiredServers(n int) int {
+	required := 1
+	switch c.TrustPolicy {
+	case TrustPolicyAll:
+		required = n
+	case TrustPolicyQuorum:
+		required = c.TrustQuorum
+	}
+	return max(required, 1)
+}
+
+// validateServers checks the trust policy of Config against the number of
+// trusted servers n and returns a ValidationError if a quorum can never be
+// reached. Without servers, the quorum is not checked, so servers can still
+// be set after the Config.
+func (c *Config) validateServers(n int) error {
+	if n > 0 && c.TrustPolicy == TrustPolicyQuorum && c.TrustQuorum > n {
+		return &ValidationError{
+			Field: "TrustQuorum",
+			Err: fmt.Errorf("quorum %d is greater than the number of servers %d",
+				c.TrustQuorum, n),
+		}
+	}
+	return nil
+}
+
+// routeFilter returns the filter of route changes in Config.
+func (c *Config) routeFilter() *routes.Filter {
+	f := &routes.Filter{
+		Tables:           c.RouteTables,
+		Protocols:        c.RouteProtocols,
+		IgnoreInterfaces: c.RouteIgnoreInterfaces,
+		DefaultOnly:      c.RouteDefaultOnly,
+	}
+	for _, family := range c.RouteFamilies {
+		switch family {
+		case 4:
+			f.Families = append(f.Families, unix.AF_INET)
+		case 6:
+			f.Families = append(f.Families, unix.AF_INET6)
+		}
 	}
-	return true
+	return f
 }
 
 // NewConfig returns a new Config.
 func NewConfig() *Config {
 	return &Config{
-		WatchFiles:     append(WatchFiles[:0:0], WatchFiles...),
-		WaitCheck:      WaitCheck,
-		HTTPSTimeout:   HTTPSTimeout,
-		UntrustedTimer: UntrustedTimer,
-		TrustedTimer:   TrustedTimer,
+		WatchFiles:       append(WatchFiles[:0:0], WatchFiles...),
+		WaitCheck:        WaitCheck,
+		HTTPSTimeout:     HTTPSTimeout,
+		UntrustedTimer:   UntrustedTimer,
+		TrustedTimer:     TrustedTimer,
+		TrustPolicy:      TrustPolicyAny,
+		ProbeTimeout:     ProbeTimeout,
+		DebounceQuiet:    DebounceQuiet,
+		DebounceMaxDelay: DebounceMaxDelay,
 	}
 }
@@ -1,9 +1,13 @@
 package tnd
 
 import (
+	"errors"
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/telekom-mms/tnd/internal/routes"
+	"golang.org/x/sys/unix"
 )
 
 // TestConfigCopy tests Copy of Config.
@@ -26,19 +30,71 @@ func TestConfigCopy(t *testing.T) {
 	if reflect.DeepEqual(c1, c2) {
 		t.Error("copies should not be equal after modification")
 	}
+
+	// test modification of route filter after copy
+	c1 = NewConfig()
+	c1.RouteTables = []int{254}
+	c1.RouteProtocols = []int{16}
+	c1.RouteIgnoreInterfaces = []string{"tun0"}
+	c1.RouteFamilies = []int{4}
+	c2 = c1.Copy()
+
+	c1.RouteTables[0] = 255
+	c1.RouteProtocols[0] = 3
+	c1.RouteIgnoreInterfaces[0] = "tun1"
+	c1.RouteFamilies[0] = 6
+
+	if c2.RouteTables[0] != 254 || c2.RouteProtocols[0] != 16 ||
+		c2.RouteIgnoreInterfaces[0] != "tun0" || c2.RouteFamilies[0] != 4 {
+		t.Errorf("copy should not be modified: %v", c2)
+	}
 }
 
 // TestConfigValid tests Valid of Config.
 func TestConfigValid(t *testing.T) {
 	// test invalid
+	// newConfig returns a config with all durations set to d
+	newConfig := func(files []string, d time.Duration) *Config {
+		return &Config{
+			WatchFiles:     files,
+			WaitCheck:      d,
+			HTTPSTimeout:   d,
+			UntrustedTimer: d,
+			TrustedTimer:   d,
+		}
+	}
+	// modConfig returns a valid config modified with f
+	modConfig := func(f func(c *Config)) *Config {
+		c := newConfig(WatchFiles, 99)
+		f(c)
+		return c
+	}
 	for _, invalid := range []*Config{
 		nil,
-		{nil, 99, 99, 99, 99},
-		{[]string{}, 99, 99, 99, 99},
-		{WatchFiles, -1, 99, 99, 99},
-		{WatchFiles, 99, -1, 99, 99},
-		{WatchFiles, 99, 99, -1, 99},
-		{WatchFiles, 99, 99, 99, -1},
+		newConfig(nil, 99),
+		newConfig([]string{}, 99),
+		{WatchFiles: WatchFiles, WaitCheck: -1, HTTPSTimeout: 99, UntrustedTimer: 99, TrustedTimer: 99},
+		{WatchFiles: WatchFiles, WaitCheck: 99, HTTPSTimeout: -1, UntrustedTimer: 99, TrustedTimer: 99},
+		{WatchFiles: WatchFiles, WaitCheck: 99, HTTPSTimeout: 99, UntrustedTimer: -1, TrustedTimer: 99},
+		{WatchFiles: WatchFiles, WaitCheck: 99, HTTPSTimeout: 99, UntrustedTimer: 99, TrustedTimer: -1},
+		newConfig(WatchFiles, 0),
+		modConfig(func(c *Config) { c.ProbeTimeout = -1 }),
+		modConfig(func(c *Config) { c.DebounceQuiet = -1 }),
+		modConfig(func(c *Config) {
+			c.DebounceQuiet = 2
+			c.DebounceMaxDelay = 1
+		}),
+		modConfig(func(c *Config) { c.RouteTables = []int{-1} }),
+		modConfig(func(c *Config) { c.RouteProtocols = []int{256} }),
+		modConfig(func(c *Config) { c.RouteIgnoreInterfaces = []string{""} }),
+		modConfig(func(c *Config) { c.RouteFamilies = []int{5} }),
+		modConfig(func(c *Config) { c.TrustPolicy = -1 }),
+		modConfig(func(c *Config) { c.TrustPolicy = 3 }),
+		modConfig(func(c *Config) { c.TrustPolicy = TrustPolicyQuorum }),
+		modConfig(func(c *Config) {
+			c.TrustPolicy = TrustPolicyQuorum
+			c.TrustQuorum = -1
+		}),
 	} {
 		if invalid.Valid() {
 			t.Errorf("Config should be invalid: %v", invalid)
@@ -48,7 +104,25 @@ func TestConfigValid(t *testing.T) {
 	// test valid
 	for _, valid := range []*Config{
 		NewConfig(),
-		{WatchFiles, 1000000000, 1000000000, 1000000000, 1000000000},
+		newConfig(WatchFiles, 1000000000),
+		modConfig(func(c *Config) { c.WaitCheck = 0 }),
+		modConfig(func(c *Config) { c.TrustPolicy = TrustPolicyAll }),
+		modConfig(func(c *Config) { c.ParallelProbes = true }),
+		modConfig(func(c *Config) {
+			c.DebounceQuiet = 1
+			c.DebounceMaxDelay = 1
+		}),
+		modConfig(func(c *Config) {
+			c.RouteTables = []int{0, 254, 255}
+			c.RouteProtocols = []int{0, 255}
+			c.RouteIgnoreInterfaces = []string{"tun0"}
+			c.RouteDefaultOnly = true
+			c.RouteFamilies = []int{4, 6}
+		}),
+		modConfig(func(c *Config) {
+			c.TrustPolicy = TrustPolicyQuorum
+			c.TrustQuorum = 2
+		}),
 	} {
 		if !valid.Valid() {
 			t.Errorf("Config should be valid: %v", valid)
@@ -56,6 +130,184 @@ func TestConfigValid(t *testing.T) {
 	}
 }
 
+// TestConfigValidate tests Validate of Config.
+func TestConfigValidate(t *testing.T) {
+	// test valid
+	if err := NewConfig().Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// test invalid
+	var nilConfig *Config
+	if err := nilConfig.Validate(); err == nil {
+		t.Error("nil config should be invalid")
+	}
+	for field, modify := range map[string]func(c *Config){
+		"WatchFiles":     func(c *Config) { c.WatchFiles = nil },
+		"WaitCheck":      func(c *Config) { c.WaitCheck = -1 },
+		"HTTPSTimeout":   func(c *Config) { c.HTTPSTimeout = 0 },
+		"UntrustedTimer": func(c *Config) { c.UntrustedTimer = 0 },
+		"TrustedTimer":   func(c *Config) { c.TrustedTimer = -1 },
+		"ProbeTimeout":   func(c *Config) { c.ProbeTimeout = -1 },
+		"DebounceQuiet":  func(c *Config) { c.DebounceQuiet = -1 },
+		"DebounceMaxDelay": func(c *Config) {
+			c.DebounceMaxDelay = c.DebounceQuiet - 1
+		},
+		"RouteTables[1]": func(c *Config) {
+			c.RouteTables = []int{254, -1}
+		},
+		"RouteProtocols[0]": func(c *Config) {
+			c.RouteProtocols = []int{-1}
+		},
+		"RouteIgnoreInterfaces[0]": func(c *Config) {
+			c.RouteIgnoreInterfaces = []string{""}
+		},
+		"RouteFamilies[1]": func(c *Config) {
+			c.RouteFamilies = []int{4, 10}
+		},
+		"TrustPolicy": func(c *Config) { c.TrustPolicy = 5 },
+		"TrustQuorum": func(c *Config) { c.TrustPolicy = TrustPolicyQuorum },
+	} {
+		c := NewConfig()
+		modify(c)
+		var err *ValidationError
+		if !errors.As(c.Validate(), &err) || err.Field != field {
+			t.Errorf("got %v, want error in %s", err, field)
+		}
+	}
+}
+
+// TestConfigValidateServers tests validateServers of Config.
+func TestConfigValidateServers(t *testing.T) {
+	c := NewConfig()
+	c.TrustPolicy = TrustPolicyQuorum
+	c.TrustQuorum = 2
+
+	// test valid
+	for _, n := range []int{0, 2, 3} {
+		if err := c.validateServers(n); err != nil {
+			t.Errorf("%d servers should be valid: %v", n, err)
+		}
+	}
+	if err := NewConfig().validateServers(1); err != nil {
+		t.Errorf("policy any should be valid: %v", err)
+	}
+
+	// test invalid
+	var err *ValidationError
+	if !errors.As(c.validateServers(1), &err) || err.Field != "TrustQuorum" {
+		t.Errorf("got %v, want error in TrustQuorum", err)
+	}
+}
+
+// TestConfigRouteFilter tests routeFilter of Config.
+func TestConfigRouteFilter(t *testing.T) {
+	// test default
+	if got := NewConfig().routeFilter(); !reflect.DeepEqual(got, &routes.Filter{}) {
+		t.Errorf("got %v, want empty filter", got)
+	}
+
+	// test all settings
+	c := NewConfig()
+	c.RouteTables = []int{254}
+	c.RouteProtocols = []int{3, 16}
+	c.RouteIgnoreInterfaces = []string{"tun0"}
+	c.RouteDefaultOnly = true
+	c.RouteFamilies = []int{4, 6}
+	want := &routes.Filter{
+		Tables:           []int{254},
+		Protocols:        []int{3, 16},
+		IgnoreInterfaces: []string{"tun0"},
+		DefaultOnly:      true,
+		Families:         []int{unix.AF_INET, unix.AF_INET6},
+	}
+	if got := c.routeFilter(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestValidationError tests ValidationError.
+func TestValidationError(t *testing.T) {
+	reason := errors.New("test error")
+	err := &ValidationError{Field: "Test", Err: reason}
+	if err.Error() != "invalid Test: test error" {
+		t.Errorf("invalid error string: %s", err)
+	}
+	if !errors.Is(err, reason) {
+		t.Error("validation error should wrap reason")
+	}
+}
+
+// TestConfigRequiredServers tests requiredServers of Config.
+func TestConfigRequiredServers(t *testing.T) {
+	for _, test := range []struct {
+		policy TrustPolicy
+		quorum int
+		n      int
+		want   int
+	}{
+		{TrustPolicyAny, 0, 0, 1},
+		{TrustPolicyAny, 0, 3, 1},
+		{TrustPolicyAll, 0, 0, 1},
+		{TrustPolicyAll, 0, 3, 3},
+		{TrustPolicyQuorum, 2, 3, 2},
+		{TrustPolicyQuorum, 4, 3, 4},
+	} {
+		c := NewConfig()
+		c.TrustPolicy = test.policy
+		c.TrustQuorum = test.quorum
+		got := c.requiredServers(test.n)
+		if got != test.want {
+			t.Errorf("%v: got %d, want %d", test, got, test.want)
+		}
+	}
+}
+
+// TestTrustPolicyString tests String of TrustPolicy.
+func TestTrustPolicyString(t *testing.T) {
+	for policy, want := range map[TrustPolicy]string{
+		TrustPolicyAny:    "any",
+		TrustPolicyAll:    "all",
+		TrustPolicyQuorum: "quorum",
+		TrustPolicy(-1):   "unknown",
+	} {
+		got := policy.String()
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+// TestTrustPolicyText tests MarshalText and UnmarshalText of TrustPolicy.
+func TestTrustPolicyText(t *testing.T) {
+	for _, want := range []TrustPolicy{
+		TrustPolicyAny,
+		TrustPolicyAll,
+		TrustPolicyQuorum,
+	} {
+		text, err := want.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got TrustPolicy
+		if err := got.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+
+	// test invalid
+	if _, err := TrustPolicy(-1).MarshalText(); err == nil {
+		t.Error("unknown trust policy should return error")
+	}
+	var p TrustPolicy
+	if err := p.UnmarshalText([]byte("unknown")); err == nil {
+		t.Error("unknown trust policy should return error")
+	}
+}
+
 // TestNewConfig tests NewConfig.
 func TestNewConfig(t *testing.T) {
 	c := NewConfig()
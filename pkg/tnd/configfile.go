@@ -0,0 +1,261 @@
+package tnd
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FileConfig is a TND configuration loaded from a config file with
+// LoadConfig.
+type FileConfig struct {
+	// Config is the Config with the settings of the config file.
+	// Settings that are not in the config file have the default values
+	// of NewConfig.
+	Config *Config
+
+	// Servers are the trusted https servers.
+	Servers []*Server
+
+	// Dialer is the dialer for the https connections. It is nil if the
+	// config file has no dialer settings, so the current dialer is kept.
+	Dialer *net.Dialer
+}
+
+// duration is a time.Duration in a config file, e.g., "1s" or "500ms".
+type duration time.Duration
+
+// UnmarshalText parses the duration from text.
+func (d *duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+// configFile is the format of a config file.
+type configFile struct {
+	WatchFiles            []string      `json:"watch_files"`
+	WaitCheck             duration      `json:"wait_check"`
+	HTTPSTimeout          duration      `json:"https_timeout"`
+	UntrustedTimer        duration      `json:"untrusted_timer"`
+	TrustedTimer          duration      `json:"trusted_timer"`
+	TrustPolicy           TrustPolicy   `json:"trust_policy"`
+	TrustQuorum           int           `json:"trust_quorum"`
+	ParallelProbes        bool          `json:"parallel_probes"`
+	ProbeTimeout          duration      `json:"probe_timeout"`
+	DebounceQuiet         duration      `json:"debounce_quiet"`
+	DebounceMaxDelay      duration      `json:"debounce_max_delay"`
+	RouteTables           []int         `json:"route_tables"`
+	RouteProtocols        []int         `json:"route_protocols"`
+	RouteIgnoreInterfaces []string      `json:"route_ignore_interfaces"`
+	RouteDefaultOnly      bool          `json:"route_default_only"`
+	RouteFamilies         []int         `json:"route_families"`
+	Servers               []*serverFile `json:"servers"`
+	Dialer                *dialerFile   `json:"dialer"`
+}
+
+// serverFile is the format of a trusted https server in a config file.
+type serverFile struct {
+	URL            string   `json:"url"`
+	Hashes         []string `json:"hashes"`
+	MatchChain     bool     `json:"match_chain"`
+	CABundleFile   string   `json:"ca_bundle_file"`
+	VerifyHostname bool     `json:"verify_hostname"`
+}
+
+// dialerFile is the format of the dialer options in a config file.
+type dialerFile struct {
+	Timeout       duration `json:"timeout"`
+	KeepAlive     duration `json:"keep_alive"`
+	FallbackDelay duration `json:"fallback_delay"`
+	LocalAddress  string   `json:"local_address"`
+}
+
+// dialer returns the dialer for the dialer options in f.
+func (f *dialerFile) dialer() (*net.Dialer, error) {
+	dialer := &net.Dialer{
+		Timeout:       time.Duration(f.Timeout),
+		KeepAlive:     time.Duration(f.KeepAlive),
+		FallbackDelay: time.Duration(f.FallbackDelay),
+	}
+	if f.LocalAddress != "" {
+		ip := net.ParseIP(f.LocalAddress)
+		if ip == nil {
+			return nil, &ValidationError{
+				Field: "Dialer.LocalAddress",
+				Err:   fmt.Errorf("invalid ip address %q", f.LocalAddress),
+			}
+		}
+		dialer.LocalAddr = &net.TCPAddr{IP: ip}
+	}
+	return dialer, nil
+}
+
+// parseConfig parses the config file data. Relative CA bundle file paths are
+// relative to dir.
+func parseConfig(data []byte, dir string) (*FileConfig, error) {
+	// set defaults
+	c := NewConfig()
+	f := &configFile{
+		WatchFiles:            c.WatchFiles,
+		WaitCheck:             duration(c.WaitCheck),
+		HTTPSTimeout:          duration(c.HTTPSTimeout),
+		UntrustedTimer:        duration(c.UntrustedTimer),
+		TrustedTimer:          duration(c.TrustedTimer),
+		TrustPolicy:           c.TrustPolicy,
+		TrustQuorum:           c.TrustQuorum,
+		ParallelProbes:        c.ParallelProbes,
+		ProbeTimeout:          duration(c.ProbeTimeout),
+		DebounceQuiet:         duration(c.DebounceQuiet),
+		DebounceMaxDelay:      duration(c.DebounceMaxDelay),
+		RouteTables:           c.RouteTables,
+		RouteProtocols:        c.RouteProtocols,
+		RouteIgnoreInterfaces: c.RouteIgnoreInterfaces,
+		RouteDefaultOnly:      c.RouteDefaultOnly,
+		RouteFamilies:         c.RouteFamilies,
+	}
+
+	// parse config, reject unknown fields and trailing data
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(f); err != nil {
+		return nil, err
+	}
+	if err := dec.Decode(&struct{}{}); err != io.EOF {
+		return nil, errors.New("invalid data after config")
+	}
+
+	// get config
+	config := &Config{
+		WatchFiles:            f.WatchFiles,
+		WaitCheck:             time.Duration(f.WaitCheck),
+		HTTPSTimeout:          time.Duration(f.HTTPSTimeout),
+		UntrustedTimer:        time.Duration(f.UntrustedTimer),
+		TrustedTimer:          time.Duration(f.TrustedTimer),
+		TrustPolicy:           f.TrustPolicy,
+		TrustQuorum:           f.TrustQuorum,
+		ParallelProbes:        f.ParallelProbes,
+		ProbeTimeout:          time.Duration(f.ProbeTimeout),
+		DebounceQuiet:         time.Duration(f.DebounceQuiet),
+		DebounceMaxDelay:      time.Duration(f.DebounceMaxDelay),
+		RouteTables:           f.RouteTables,
+		RouteProtocols:        f.RouteProtocols,
+		RouteIgnoreInterfaces: f.RouteIgnoreInterfaces,
+		RouteDefaultOnly:      f.RouteDefaultOnly,
+		RouteFamilies:         f.RouteFamilies,
+	}
+	if err := config.Validate(); err != nil {
+		return nil, err
+	}
+
+	// get servers
+	servers := []*Server{}
+	for i, s := range f.Servers {
+		if s == nil {
+			servers = append(servers, nil)
+			continue
+		}
+		server := &Server{
+			URL:            s.URL,
+			Hashes:         s.Hashes,
+			MatchChain:     s.MatchChain,
+			VerifyHostname: s.VerifyHostname,
+		}
+		if s.CABundleFile != "" {
+			file := s.CABundleFile
+			if !filepath.IsAbs(file) {
+				file = filepath.Join(dir, file)
+			}
+			b, err := os.ReadFile(file)
+			if err != nil {
+				return nil, &ValidationError{
+					Field: fmt.Sprintf("Servers[%d].CABundle", i),
+					Err:   err,
+				}
+			}
+			server.CABundle = b
+		}
+		servers = append(servers, server)
+	}
+	if err := ValidateServers(servers); err != nil {
+		return nil, err
+	}
+
+	// get dialer
+	var dialer *net.Dialer
+	if f.Dialer != nil {
+		d, err := f.Dialer.dialer()
+		if err != nil {
+			return nil, err
+		}
+		dialer = d
+	}
+
+	return &FileConfig{
+		Config:  config,
+		Servers: servers,
+		Dialer:  dialer,
+	}, nil
+}
+
+// LoadConfig loads the TND configuration from the JSON config file at path.
+// It returns an error if the file cannot be parsed, contains unknown fields
+// or if the configuration is invalid; validation errors are ValidationErrors.
+//
+// Durations are strings like "1s" or "500ms", the trust policy is "any",
+// "all" or "quorum" and relative CA bundle files are relative to the
+// directory of the config file. Example:
+//
+//	{
+//		"watch_files": ["/etc/resolv.conf"],
+//		"wait_check": "1s",
+//		"https_timeout": "5s",
+//		"untrusted_timer": "30s",
+//		"trusted_timer": "60s",
+//		"trust_policy": "quorum",
+//		"trust_quorum": 2,
+//		"parallel_probes": true,
+//		"probe_timeout": "10s",
+//		"debounce_quiet": "500ms",
+//		"debounce_max_delay": "2s",
+//		"route_tables": [254],
+//		"route_protocols": [3, 4, 16],
+//		"route_ignore_interfaces": ["tun0"],
+//		"route_default_only": true,
+//		"route_families": [4, 6],
+//		"servers": [
+//			{
+//				"url": "https://trusted1.mynetwork.com",
+//				"hashes": ["pin-sha256:<base64>", "sha256:<hex>"],
+//				"match_chain": true,
+//				"ca_bundle_file": "ca.pem",
+//				"verify_hostname": true
+//			}
+//		],
+//		"dialer": {
+//			"timeout": "5s",
+//			"keep_alive": "30s",
+//			"fallback_delay": "300ms",
+//			"local_address": "192.168.1.2"
+//		}
+//	}
+func LoadConfig(path string) (*FileConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c, err := parseConfig(data, filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("could not load config file %s: %w", path, err)
+	}
+	return c, nil
+}
@@ -0,0 +1,169 @@
+package tnd
+
+import (
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeConfigFile writes the config file data to a temporary directory and
+// returns its path.
+func writeConfigFile(t *testing.T, data string) string {
+	path := filepath.Join(t.TempDir(), "tnd.json")
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadConfig tests LoadConfig.
+func TestLoadConfig(t *testing.T) {
+	// test full config
+	caBundle := testCABundle()
+	path := writeConfigFile(t, `{
+		"watch_files": ["/test/resolv.conf"],
+		"wait_check": "2s",
+		"https_timeout": "3s",
+		"untrusted_timer": "4s",
+		"trusted_timer": "5m",
+		"trust_policy": "quorum",
+		"trust_quorum": 2,
+		"parallel_probes": true,
+		"probe_timeout": "500ms",
+		"debounce_quiet": "100ms",
+		"debounce_max_delay": "1s",
+		"route_tables": [254],
+		"route_protocols": [3, 16],
+		"route_ignore_interfaces": ["tun0"],
+		"route_default_only": true,
+		"route_families": [4],
+		"servers": [
+			{
+				"url": "https://trusted1.example.com",
+				"hashes": ["`+testHash("hash1")+`", "`+testHash("hash2")+`"]
+			},
+			{
+				"url": "https://trusted2.example.com",
+				"hashes": ["`+testHash("hash3")+`"],
+				"match_chain": true,
+				"ca_bundle_file": "ca.pem",
+				"verify_hostname": true
+			}
+		],
+		"dialer": {
+			"timeout": "1s",
+			"keep_alive": "30s",
+			"fallback_delay": "300ms",
+			"local_address": "127.0.0.1"
+		}
+	}`)
+	if err := os.WriteFile(filepath.Join(filepath.Dir(path), "ca.pem"),
+		caBundle, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := &FileConfig{
+		Config: &Config{
+			WatchFiles:            []string{"/test/resolv.conf"},
+			WaitCheck:             2 * time.Second,
+			HTTPSTimeout:          3 * time.Second,
+			UntrustedTimer:        4 * time.Second,
+			TrustedTimer:          5 * time.Minute,
+			TrustPolicy:           TrustPolicyQuorum,
+			TrustQuorum:           2,
+			ParallelProbes:        true,
+			ProbeTimeout:          500 * time.Millisecond,
+			DebounceQuiet:         100 * time.Millisecond,
+			DebounceMaxDelay:      time.Second,
+			RouteTables:           []int{254},
+			RouteProtocols:        []int{3, 16},
+			RouteIgnoreInterfaces: []string{"tun0"},
+			RouteDefaultOnly:      true,
+			RouteFamilies:         []int{4},
+		},
+		Servers: []*Server{
+			{
+				URL:    "https://trusted1.example.com",
+				Hashes: []string{testHash("hash1"), testHash("hash2")},
+			},
+			{
+				URL:            "https://trusted2.example.com",
+				Hashes:         []string{testHash("hash3")},
+				MatchChain:     true,
+				CABundle:       caBundle,
+				VerifyHostname: true,
+			},
+		},
+		Dialer: &net.Dialer{
+			Timeout:       time.Second,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: 300 * time.Millisecond,
+			LocalAddr:     &net.TCPAddr{IP: net.ParseIP("127.0.0.1")},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// test empty config, defaults should be used
+	got, err = LoadConfig(writeConfigFile(t, `{}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = &FileConfig{
+		Config:  NewConfig(),
+		Servers: []*Server{},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// test not existing file
+	if _, err := LoadConfig(filepath.Join(t.TempDir(), "missing.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want not exist error", err)
+	}
+
+	// test invalid
+	for _, invalid := range []string{
+		``,
+		`[]`,
+		`{"unknown": true}`,
+		`{"servers": [{"url": "https://test.example.com", "unknown": 1}]}`,
+		`{"dialer": {"unknown": 1}}`,
+		`{"wait_check": 1}`,
+		`{"wait_check": "1 second"}`,
+		`{"trust_policy": "some"}`,
+		`{"trust_quorum": "2"}`,
+		`{} {}`,
+	} {
+		if _, err := LoadConfig(writeConfigFile(t, invalid)); err == nil {
+			t.Errorf("config should be invalid: %s", invalid)
+		}
+	}
+
+	// test validation errors
+	for field, invalid := range map[string]string{
+		"HTTPSTimeout":         `{"https_timeout": "0s"}`,
+		"WatchFiles":           `{"watch_files": []}`,
+		"TrustQuorum":          `{"trust_policy": "quorum"}`,
+		"Servers[0]":           `{"servers": [null]}`,
+		"Servers[0].URL":       `{"servers": [{"url": "http://test"}]}`,
+		"Servers[0].Hashes[0]": `{"servers": [{"url": "https://test", "hashes": ["invalid"]}]}`,
+		"Servers[0].CABundle":  `{"servers": [{"url": "https://test", "hashes": ["` + testHash("test") + `"], "ca_bundle_file": "missing.pem"}]}`,
+		"Dialer.LocalAddress":  `{"dialer": {"local_address": "invalid"}}`,
+	} {
+		var err *ValidationError
+		_, e := LoadConfig(writeConfigFile(t, invalid))
+		if !errors.As(e, &err) || err.Field != field {
+			t.Errorf("got %v, want error in %s", e, field)
+		}
+	}
+}
@@ -1,101 +1,595 @@
 package tnd
 
 import (
+	"context"
+	"errors"
 	"math/rand/v2"
 	"net"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sync"
 	"time"
 
-	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/debounce"
 	"github.com/telekom-mms/tnd/internal/files"
 	"github.com/telekom-mms/tnd/internal/https"
+	"github.com/telekom-mms/tnd/internal/logging"
 	"github.com/telekom-mms/tnd/internal/routes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// update is a runtime update of the Detector's configuration, servers or
+// dialer; nil fields are not changed. The main loop reports whether the
+// update was applied over err.
+type update struct {
+	config  *Config
+	servers []*https.Server
+	dialer  *net.Dialer
+	err     chan error
+}
+
 // Detector realizes the trusted network detection.
 type Detector struct {
+	probes          chan struct{}
+	results         chan bool
+	detailedResults chan *Result
+	done            chan struct{}
+	updates         chan *update
+	errors          chan error
+
+	// mutex for config, servers, dialer, metrics, tracer and logger, is
+	// the detector started?
+	mu      sync.Mutex
 	config  *Config
-	probes  chan struct{}
-	results chan bool
-	done    chan struct{}
 	servers []*https.Server
 	dialer  *net.Dialer
-
-	// route and file watch
-	rw routes.Watcher
-	fw files.Watcher
+	metrics Metrics
+	tracer  trace.Tracer
+	logger  Logger
+	started bool
+
+	// route and file watch and their probe channels
+	rw          routes.Watcher
+	fw          files.Watcher
+	routeProbes chan struct{}
+	fileProbes  chan struct{}
+
+	// config file and its watch and probe channel
+	configFile   string
+	cw           files.Watcher
+	configProbes chan struct{}
 
 	// timer
 	timer *time.Timer
 
+	// clock, debouncer of route and file events and trigger of the
+	// first event of the current burst
+	clock           debounce.Clock
+	debouncer       *debounce.Debouncer
+	debounceTrigger Trigger
+
 	// probe result channel and probe function
-	probeResults chan bool
+	probeResults chan *Result
+
+	// is the network trusted, is a probe currently running? result and
+	// cancel function of the running probe
+	trusted bool
+	running bool
+	probing *Result
+	cancel  context.CancelFunc
+}
+
+// PinPrefix is the prefix of server hashes that pin the SHA-256 hash of the
+// server certificate's public key (SubjectPublicKeyInfo) as in HPKP
+// pin-sha256 instead of the full certificate, e.g., "pin-sha256:<base64>".
+// The pinned hash can be base64 or hex encoded.
+const PinPrefix = https.PinPrefix
+
+// setUpdate sets the config, servers and dialer in u. If the Detector is
+// running, the update is applied by the main loop, which immediately triggers
+// a new probe, and the error of the update is returned.
+func (d *Detector) setUpdate(u *update) error {
+	d.mu.Lock()
+	if !d.started {
+		if err := d.validateUpdate(u); err != nil {
+			d.mu.Unlock()
+			return err
+		}
+
+		// recreate file watch with new watch files before Start()
+		if u.config != nil && !slices.Equal(u.config.WatchFiles, d.config.WatchFiles) {
+			d.fw = filesNewWatch(d.fileProbes, u.config.WatchFiles, d.logger)
+		}
+		d.applyUpdate(u)
+		d.mu.Unlock()
+		return nil
+	}
+	d.mu.Unlock()
+
+	u.err = make(chan error, 1)
+	select {
+	case d.updates <- u:
+	case <-d.done:
+		return nil
+	}
+	select {
+	case err := <-u.err:
+		return err
+	case <-d.done:
+		return nil
+	}
+}
+
+// validateUpdate checks the update u against the current config and
+// servers, so that a quorum can still be reached after the update; d.mu must
+// be held by the caller.
+func (d *Detector) validateUpdate(u *update) error {
+	config, n := d.config, len(d.servers)
+	if u.config != nil {
+		config = u.config
+	}
+	if u.servers != nil {
+		n = len(u.servers)
+	}
+	return config.validateServers(n)
+}
+
+// applyUpdate applies the update u, d.mu must be held by the caller.
+func (d *Detector) applyUpdate(u *update) {
+	if u.config != nil {
+		d.config = u.config
+	}
+	if u.servers != nil {
+		d.servers = u.servers
+	}
+	if u.dialer != nil {
+		d.dialer = u.dialer
+	}
+}
+
+// SetConfig sets the Config. If the Detector is running, the new timers and
+// watch files are applied and a new probe is triggered. If the Config is
+// invalid or its TrustQuorum is greater than the number of servers, a
+// ValidationError is returned and the Config is not changed.
+func (d *Detector) SetConfig(config *Config) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	return d.setUpdate(&update{config: config.Copy()})
+}
+
+// GetConfig returns a copy of the Config.
+func (d *Detector) GetConfig() *Config {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	// is the network trusted, are probes currently running or
-	// have to run again?
-	trusted  bool
-	running  bool
-	runAgain bool
+	return d.config.Copy()
 }
 
 // SetServers sets the https server urls and their expected hashes in the
 // servers map as trusted servers; map key is the server url, value is the
-// server's hash. Note: servers must be set before Start().
+// server's hash. See ValidateHash for the hash formats. Servers with invalid
+// hashes are rejected. If there are less servers than the TrustQuorum of the
+// Config, all servers are rejected and the servers are not changed. If the
+// Detector is running, a new probe is triggered.
 func (d *Detector) SetServers(servers map[string]string) {
-	d.servers = []*https.Server{}
+	s := []*https.Server{}
 	for url, hash := range servers {
-		server := https.NewServer(url, hash)
-		d.servers = append(d.servers, server)
+		server, err := https.NewServer(url, hash)
+		if err != nil {
+			d.logger.Error("TND rejected https server with invalid hash",
+				FieldURL, url, FieldError, err)
+			continue
+		}
+		s = append(s, server)
+	}
+	if err := d.setUpdate(&update{servers: s}); err != nil {
+		d.logger.Error("TND rejected https servers", FieldError, err)
 	}
 }
 
 // GetServers returns the https servers as map; map key is the server url,
-// value is the server's hash.
+// value is the server's hash. If a server has multiple hashes, only the first
+// hash is returned; use GetTrustedServers to get all hashes.
 func (d *Detector) GetServers() map[string]string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	servers := make(map[string]string)
 	for _, s := range d.servers {
-		servers[s.URL] = s.Hash
+		hash := ""
+		if len(s.Hashes) > 0 {
+			hash = s.Hashes[0]
+		}
+		servers[s.URL] = hash
+	}
+	return servers
+}
+
+// newHTTPSServers returns the https servers for the trusted servers.
+func newHTTPSServers(servers []*Server) ([]*https.Server, error) {
+	s := []*https.Server{}
+	for _, server := range servers {
+		hs, err := https.NewServer(server.URL, server.Hashes...)
+		if err != nil {
+			return nil, err
+		}
+		hs.MatchChain = server.MatchChain
+		hs.CABundle = append(server.CABundle[:0:0], server.CABundle...)
+		hs.VerifyHostname = server.VerifyHostname
+		s = append(s, hs)
+	}
+	return s, nil
+}
+
+// SetTrustedServers sets the trusted https servers. Each server can have
+// multiple accepted hashes, e.g., to allow certificate rotation. If one of
+// the servers is invalid or there are less servers than the TrustQuorum of
+// the Config, a ValidationError is returned and the servers are not
+// changed. If the Detector is running, a new probe is triggered.
+func (d *Detector) SetTrustedServers(servers []*Server) error {
+	if err := ValidateServers(servers); err != nil {
+		return err
+	}
+
+	s, err := newHTTPSServers(servers)
+	if err != nil {
+		return err
+	}
+	return d.setUpdate(&update{servers: s})
+}
+
+// GetTrustedServers returns the trusted https servers.
+func (d *Detector) GetTrustedServers() []*Server {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	servers := []*Server{}
+	for _, s := range d.servers {
+		servers = append(servers, &Server{
+			URL:            s.URL,
+			Hashes:         append(s.Hashes[:0:0], s.Hashes...),
+			MatchChain:     s.MatchChain,
+			CABundle:       append(s.CABundle[:0:0], s.CABundle...),
+			VerifyHostname: s.VerifyHostname,
+		})
 	}
 	return servers
 }
 
-// SetDialer sets a custom dialer for the https connections; note: the dialer
-// must be set before Start().
+// SetDialer sets a custom dialer for the https connections. If the Detector
+// is running, a new probe is triggered.
 func (d *Detector) SetDialer(dialer *net.Dialer) {
-	d.dialer = dialer
+	if dialer == nil {
+		return
+	}
+	_ = d.setUpdate(&update{dialer: dialer})
 }
 
 // GetDialer returns the custom dialer for the https connections.
 func (d *Detector) GetDialer() *net.Dialer {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
 	return d.dialer
 }
 
-// sendResult sends result over channel c.
-func (d *Detector) sendResult(c chan bool, result bool) {
+// SetFileConfig sets the Config, the trusted servers and the dialer in f
+// together, e.g., after loading a config file with LoadConfig. If the
+// Detector is running, they are applied at once and a single new probe is
+// triggered. If f is invalid, a ValidationError is returned and nothing is
+// changed.
+func (d *Detector) SetFileConfig(f *FileConfig) error {
+	if f == nil {
+		return &ValidationError{
+			Field: "FileConfig",
+			Err:   errors.New("file config is nil"),
+		}
+	}
+	if err := f.Config.Validate(); err != nil {
+		return err
+	}
+	if err := ValidateServers(f.Servers); err != nil {
+		return err
+	}
+	if err := f.Config.validateServers(len(f.Servers)); err != nil {
+		return err
+	}
+	servers, err := newHTTPSServers(f.Servers)
+	if err != nil {
+		return err
+	}
+	return d.setUpdate(&update{
+		config:  f.Config.Copy(),
+		servers: servers,
+		dialer:  f.Dialer,
+	})
+}
+
+// SetConfigFile sets the config file that is watched while the Detector is
+// running, see LoadConfig for the file format. When the config file is
+// written or replaced, it is reloaded with the environment variables of
+// LoadEnv and its Config, servers and dialer are applied together; without
+// dialer settings, the current dialer is kept. Invalid config files and
+// config files without trusted servers are reported over the errors channel
+// and the current configuration is kept. The config file must be set before
+// Start().
+func (d *Detector) SetConfigFile(path string) {
+	if abs, err := filepath.Abs(path); err == nil {
+		path = abs
+	}
+	d.configFile = path
+}
+
+// SetMetrics sets the Metrics that receive the metrics of the probes; nil
+// disables metrics.
+func (d *Detector) SetMetrics(metrics Metrics) {
+	if metrics == nil {
+		metrics = nopMetrics{}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.metrics = metrics
+}
+
+// SetTracerProvider sets the OpenTelemetry TracerProvider that is used to
+// trace the probes; nil disables tracing. Every probe is traced in a span
+// with child spans for the wait before the checks and for every server
+// check, which contains the DNS, connection and TLS events of the check.
+func (d *Detector) SetTracerProvider(provider trace.TracerProvider) {
+	tracer := newTracer(provider)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tracer = tracer
+}
+
+// SetLogger sets the Logger that is used by the Detector, its route and
+// file watchers and the server checks; nil restores the default Logger that
+// logs with the standard logrus logger. Note: the Logger must be set before
+// Start().
+func (d *Detector) SetLogger(logger Logger) {
+	if logger == nil {
+		logger = logging.Default()
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.logger = logger
+	d.rw = routes.NewWatch(d.routeProbes, logger)
+	d.fw = files.NewWatch(d.fileProbes, d.config.WatchFiles, logger)
+}
+
+// getMetrics returns the current Metrics.
+func (d *Detector) getMetrics() Metrics {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return d.metrics
+}
+
+// sendError sends err to the user over the errors channel. The error is
+// dropped if the errors channel is full.
+func (d *Detector) sendError(err error) {
+	select {
+	case d.errors <- err:
+	default:
+	}
+}
+
+// sendProbeResult sends the probe result r over the probe results channel.
+func (d *Detector) sendProbeResult(r *Result) {
 	select {
-	case c <- result:
+	case d.probeResults <- r:
 	case <-d.done:
 	}
 }
 
-// probe checks the servers and sends the result back over probeResults.
-func (d *Detector) probe() {
-	for _, i := range rand.Perm(len(d.servers)) {
-		s := d.servers[i]
+// sendResult sends the result r to the user over the results channel or the
+// detailed results channel, depending on which one is read by the user.
+// Runtime updates are handled while waiting for the user, so the user can
+// update the Detector from the goroutine that reads the results.
+func (d *Detector) sendResult(r *Result) {
+	for {
+		select {
+		case d.results <- r.Trusted:
+			return
+		case d.detailedResults <- r:
+			return
+		case u := <-d.updates:
+			d.handleUpdate(u)
+		case <-d.done:
+			return
+		}
+	}
+}
+
+// prober checks the trusted servers of a single probe. It holds a snapshot
+// of the Detector's config, servers, dialer, metrics, tracer and logger, so
+// runtime updates do not affect running probes.
+type prober struct {
+	config  *Config
+	servers []*https.Server
+	dialer  *net.Dialer
+	metrics Metrics
+	tracer  trace.Tracer
+	logger  Logger
+}
+
+// newProber returns a new prober with the current config, servers and dialer.
+func (d *Detector) newProber() *prober {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return &prober{
+		config:  d.config,
+		servers: d.servers,
+		dialer:  d.dialer,
+		metrics: d.metrics,
+		tracer:  d.tracer,
+		logger:  d.logger,
+	}
+}
+
+// addServerResult adds the server check result r to result and returns
+// whether result is decided according to the trust policy. Checks aborted
+// by canceling ctx, e.g., by a newer probe or Stop(), are not recorded in
+// the metrics, expired probe timeouts are.
+func (p *prober) addServerResult(ctx context.Context, result *Result,
+	r *https.Result) bool {
+	sr := newServerResult(r)
+	result.Servers = append(result.Servers, sr)
+	if !errors.Is(ctx.Err(), context.Canceled) {
+		p.metrics.ServerChecked(sr)
+	}
+	if r.Trusted {
+		p.logger.Debug("TND https server trusted", FieldURL, r.URL)
+		if result.Server == "" {
+			result.Server = r.URL
+		}
+	} else {
+		p.logger.Debug("TND https server not trusted",
+			FieldURL, r.URL, FieldReason, r.Reason, FieldError, r.Error)
+	}
+
+	// count trusted and untrusted servers
+	trusted := 0
+	for _, s := range result.Servers {
+		if s.Trusted {
+			trusted++
+		}
+	}
+	untrusted := len(result.Servers) - trusted
+
+	// check if result is decided
+	n := len(p.servers)
+	required := p.config.requiredServers(n)
+	if trusted >= required {
+		result.Trusted = true
+		return true
+	}
+	return untrusted > n-required
+}
+
+// sleep waits for duration or until ctx is done and returns whether the
+// full duration elapsed.
+func sleep(ctx context.Context, duration time.Duration) bool {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// probeSequential checks the servers one after the other in random order
+// and adds the server results to result.
+func (p *prober) probeSequential(ctx context.Context, result *Result) {
+	for n, i := range rand.Perm(len(p.servers)) {
+		s := p.servers[i]
 		// sleep between server probes to let network settle a bit in
 		// case of a burst of routing and dns changes, e.g, when
-		// connecting to a new network
-		time.Sleep(d.config.WaitCheck)
-
-		if s.Check(d.dialer, d.config.HTTPSTimeout) {
-			// TODO: be more strict and require all trusted servers
-			// to be reachable?
-			log.WithField("url", s.URL).Debug("TND https server trusted")
-			d.sendResult(d.probeResults, true)
+		// connecting to a new network. With debouncing, the burst is
+		// already coalesced, so only sleep once before all checks
+		if (n == 0 || p.config.DebounceQuiet == 0) &&
+			!p.wait(ctx, result.Trigger) {
+			return
+		}
+
+		r := p.check(ctx, result.Trigger, s)
+		if p.addServerResult(ctx, result, r) {
+			return
+		}
+	}
+}
+
+// probeParallel checks all servers concurrently and adds the server results
+// to result. Outstanding checks are canceled when result is decided.
+func (p *prober) probeParallel(ctx context.Context, result *Result) {
+	// sleep once before server probes to let network settle a bit in
+	// case of a burst of routing and dns changes, e.g, when connecting
+	// to a new network
+	if !p.wait(ctx, result.Trigger) {
+		return
+	}
+
+	var cancel context.CancelFunc
+	if p.config.ProbeTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, p.config.ProbeTimeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	// check all servers, buffered channel lets canceled checks finish
+	// after the result is decided
+	results := make(chan *https.Result, len(p.servers))
+	for _, s := range p.servers {
+		go func() {
+			results <- p.check(ctx, result.Trigger, s)
+		}()
+	}
+	for range p.servers {
+		if p.addServerResult(ctx, result, <-results) {
 			return
 		}
-		log.WithField("url", s.URL).Debug("TND https server not trusted")
 	}
-	d.sendResult(d.probeResults, false)
+}
+
+// run checks the servers and fills result. It stops as soon as the result is
+// decided according to the trust policy or ctx is done.
+func (p *prober) run(ctx context.Context, result *Result) {
+	p.metrics.ProbeStarted(result.Trigger)
+	ctx, span := p.startProbeSpan(ctx, result)
+	defer endProbeSpan(ctx, span, result)
+
+	if p.config.ParallelProbes {
+		p.probeParallel(ctx, result)
+	} else {
+		p.probeSequential(ctx, result)
+	}
+
+	if !result.Trusted {
+		result.Server = ""
+	}
+	result.Time = time.Now()
+}
+
+// probe checks the servers, fills result and sends it back over
+// probeResults. The probe stops as soon as the result is decided according
+// to the trust policy. If ctx is canceled, the probe is aborted and the
+// result is dropped.
+func (d *Detector) probe(ctx context.Context, result *Result) {
+	d.newProber().run(ctx, result)
+	if ctx.Err() != nil {
+		d.logger.Debug("TND probe canceled", FieldTrigger, result.Trigger)
+		return
+	}
+	d.sendProbeResult(result)
+}
+
+// Check runs a single probe of the trusted servers with the trust policy of
+// the Config and returns its result. It does not require Start() and does
+// not use the route and file watchers, e.g., for one-shot checks. If ctx is
+// done before the result is decided, the incomplete result and the error of
+// ctx are returned.
+func (d *Detector) Check(ctx context.Context) (*Result, error) {
+	result := &Result{Trigger: TriggerManual}
+	p := d.newProber()
+	p.run(ctx, result)
+	if ctx.Err() != nil {
+		return result, ctx.Err()
+	}
+	p.metrics.ProbeFinished(result)
+	return result, nil
 }
 
 // resetTimer resets the periodic probe timer.
@@ -107,80 +601,260 @@ func (d *Detector) resetTimer() {
 	}
 }
 
-// handleProbeRequest handles a probe request.
-func (d *Detector) handleProbeRequest() {
-	if d.running {
-		d.runAgain = true
-		return
-	}
+// startProbe starts a new probe triggered by trigger.
+func (d *Detector) startProbe(trigger Trigger) {
+	ctx, cancel := context.WithCancel(context.Background())
 	d.running = true
-	go d.probe()
-
+	d.probing = &Result{Trigger: trigger}
+	d.cancel = cancel
+	go d.probe(ctx, d.probing)
 }
 
-// handleProbeResult handles the probe result r.
-func (d *Detector) handleProbeResult(r bool) {
-	// handle probe result
-	d.running = false
-	if d.runAgain {
-		// we must trigger another probe
-		d.runAgain = false
-		d.running = true
-		go d.probe()
+// stopProbe stops the running probe, if any.
+func (d *Detector) stopProbe() {
+	if d.cancel != nil {
+		d.cancel()
 	}
-	log.WithField("trusted", r).Debug("TND https result")
-	d.trusted = r
-	d.sendResult(d.results, r)
+	d.running = false
+	d.probing = nil
+	d.cancel = nil
+}
 
-	// reset periodic probing timer
+// handleProbeRequest handles a probe request triggered by trigger. A running
+// probe is canceled and replaced by a new probe, so its stale result is
+// never published.
+func (d *Detector) handleProbeRequest(trigger Trigger) {
 	if d.running {
-		// probing still active and new results about
-		// to arrive, so wait for them before resetting
-		// the timer
+		d.logger.Debug("TND canceling running probe", FieldTrigger, trigger)
+		d.stopProbe()
+	}
+	d.startProbe(trigger)
+}
+
+// handleProbeResult handles the probe result r.
+func (d *Detector) handleProbeResult(r *Result) {
+	// drop results of canceled probes
+	if !d.running || r != d.probing {
+		d.logger.Debug("TND dropping stale probe result",
+			FieldTrigger, r.Trigger)
 		return
 	}
+
+	// handle probe result
+	d.stopProbe()
+	d.logger.Debug("TND https result", FieldTrusted, r.Trusted,
+		FieldServer, r.Server, FieldTrigger, r.Trigger)
+	d.getMetrics().ProbeFinished(r)
+	d.trusted = r.Trusted
+	d.sendResult(r)
+
+	// reset periodic probing timer
 	if !d.timer.Stop() {
 		<-d.timer.C
 	}
 	d.resetTimer()
 }
 
+// newDebouncer returns a new debouncer for route and file events with the
+// current config.
+func (d *Detector) newDebouncer() *debounce.Debouncer {
+	return debounce.NewDebouncer(d.clock, d.config.DebounceQuiet,
+		d.config.DebounceMaxDelay)
+}
+
+// handleEvent handles a route or file event triggered by trigger. If
+// debouncing is enabled, the event is added to the current burst of events
+// and the probe is delayed until the burst is over, see handleDebounce.
+func (d *Detector) handleEvent(trigger Trigger) {
+	if d.config.DebounceQuiet == 0 {
+		d.handleProbeRequest(trigger)
+		return
+	}
+	if d.debouncer.Add() {
+		d.debounceTrigger = trigger
+	}
+}
+
+// handleDebounce handles the end of a burst of route and file events and
+// probes with the trigger of the first event of the burst.
+func (d *Detector) handleDebounce() {
+	events := d.debouncer.Fire()
+	d.logger.Debug("TND debounced events", FieldTrigger, d.debounceTrigger,
+		FieldEvents, events)
+	d.handleProbeRequest(d.debounceTrigger)
+}
+
 // handleTimer handles a timer event.
 func (d *Detector) handleTimer() {
-	if !d.running && !d.runAgain {
+	if !d.running {
 		// no probes active, trigger new probe
-		log.Debug("TND periodic probe timer")
-		d.running = true
-		go d.probe()
+		d.logger.Debug("TND periodic probe timer")
+		d.startProbe(TriggerTimer)
 	}
 
 	// reset timer
 	d.resetTimer()
 }
 
+// filesNewWatch is files.NewWatch for testing.
+var filesNewWatch = func(probes chan struct{}, watchFiles []string, logger Logger) files.Watcher {
+	return files.NewWatch(probes, watchFiles, logger)
+}
+
+// handleUpdate handles the runtime update u. If the watch files changed, the
+// file watch is restarted; if this fails, the update is rejected. Otherwise,
+// the update is applied, the timer is reset and a new probe is triggered.
+func (d *Detector) handleUpdate(u *update) {
+	// check update against current config and servers
+	d.mu.Lock()
+	err := d.validateUpdate(u)
+	d.mu.Unlock()
+	if err != nil {
+		d.logger.Error("TND could not apply config update", FieldError, err)
+		u.err <- err
+		return
+	}
+
+	// restart file watching with new watch files
+	if u.config != nil && !slices.Equal(u.config.WatchFiles, d.config.WatchFiles) {
+		fw := filesNewWatch(d.fileProbes, u.config.WatchFiles, d.logger)
+		if err := fw.Start(); err != nil {
+			d.logger.Error("TND could not apply config update",
+				FieldError, err)
+			u.err <- err
+			return
+		}
+		d.fw.Stop()
+		d.fw = fw
+	}
+
+	// apply update
+	d.mu.Lock()
+	d.applyUpdate(u)
+	d.mu.Unlock()
+	u.err <- nil
+	d.logger.Debug("TND applied config update")
+
+	// drop pending events, they are covered by the new probe, and
+	// debounce and filter route events with new config
+	d.debouncer.Stop()
+	d.debouncer = d.newDebouncer()
+	d.rw.SetFilter(d.config.routeFilter())
+
+	// reset periodic probing timer and probe with new config
+	if !d.timer.Stop() {
+		<-d.timer.C
+	}
+	d.resetTimer()
+	d.handleProbeRequest(TriggerConfig)
+}
+
+// handleConfigFile handles a change of the config file. The config file is
+// reloaded and applied if it changed. If the config file is invalid, the error
+// is sent over the errors channel and the current configuration is kept.
+func (d *Detector) handleConfigFile() {
+	// load config file, environment variables take precedence
+	c, err := LoadConfig(d.configFile)
+	if err == nil {
+		err = c.LoadEnv()
+	}
+	if err == nil && len(c.Servers) == 0 {
+		// reject config files without servers, the network would
+		// silently become untrusted
+		err = &ValidationError{
+			Field: "Servers",
+			Err:   errors.New("no trusted servers"),
+		}
+	}
+	if err == nil {
+		err = c.Config.validateServers(len(c.Servers))
+	}
+	if err != nil {
+		d.logger.Error("TND could not reload config file",
+			FieldFile, d.configFile, FieldError, err)
+		d.sendError(err)
+		return
+	}
+	servers, err := newHTTPSServers(c.Servers)
+	if err != nil {
+		d.logger.Error("TND could not reload config file",
+			FieldFile, d.configFile, FieldError, err)
+		d.sendError(err)
+		return
+	}
+
+	// ignore unchanged config file
+	if reflect.DeepEqual(c.Config, d.config) &&
+		reflect.DeepEqual(servers, d.servers) &&
+		(c.Dialer == nil || reflect.DeepEqual(c.Dialer, d.dialer)) {
+		d.logger.Debug("TND config file unchanged", FieldFile, d.configFile)
+		return
+	}
+
+	// apply config file
+	d.logger.Info("TND reloading config file", FieldFile, d.configFile)
+	u := &update{
+		config:  c.Config,
+		servers: servers,
+		dialer:  c.Dialer,
+		err:     make(chan error, 1),
+	}
+	d.handleUpdate(u)
+	if err := <-u.err; err != nil {
+		d.sendError(err)
+	}
+}
+
 // start starts the trusted network detection.
 func (d *Detector) start() {
 	// signal stop to user via results
 	defer close(d.results)
+	defer close(d.detailedResults)
+	defer close(d.errors)
 	defer d.rw.Stop()
-	defer d.fw.Stop()
+	defer func() { d.fw.Stop() }()
+	defer func() {
+		if d.cw != nil {
+			d.cw.Stop()
+		}
+	}()
 
 	// set timer for periodic checks
 	d.timer = time.NewTimer(d.config.UntrustedTimer)
 
+	// set debouncer for route and file events
+	d.debouncer = d.newDebouncer()
+
 	// main loop
 	for {
 		select {
 		case <-d.probes:
-			d.handleProbeRequest()
+			d.handleProbeRequest(TriggerManual)
+
+		case <-d.routeProbes:
+			d.handleEvent(TriggerRoute)
+
+		case <-d.fileProbes:
+			d.handleEvent(TriggerFile)
+
+		case <-d.debouncer.C():
+			d.handleDebounce()
 
 		case r := <-d.probeResults:
 			d.handleProbeResult(r)
 
+		case u := <-d.updates:
+			d.handleUpdate(u)
+
+		case <-d.configProbes:
+			d.handleConfigFile()
+
 		case <-d.timer.C:
 			d.handleTimer()
 
 		case <-d.done:
+			d.stopProbe()
+			d.debouncer.Stop()
 			if !d.timer.Stop() {
 				<-d.timer.C
 			}
@@ -189,9 +863,23 @@ func (d *Detector) start() {
 	}
 }
 
-// Start starts the trusted network detection.
+// Start starts the trusted network detection. It returns a ValidationError if
+// the Config is invalid or there are less trusted servers than its
+// TrustQuorum.
 func (d *Detector) Start() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// check config
+	if err := d.config.Validate(); err != nil {
+		return err
+	}
+	if err := d.config.validateServers(len(d.servers)); err != nil {
+		return err
+	}
+
 	// start route watching
+	d.rw.SetFilter(d.config.routeFilter())
 	if err := d.rw.Start(); err != nil {
 		return err
 	}
@@ -202,7 +890,18 @@ func (d *Detector) Start() error {
 		return err
 	}
 
+	// start config file watching
+	if d.configFile != "" {
+		d.cw = filesNewWatch(d.configProbes, []string{d.configFile}, d.logger)
+		if err := d.cw.Start(); err != nil {
+			d.rw.Stop()
+			d.fw.Stop()
+			return err
+		}
+	}
+
 	// start detector
+	d.started = true
 	go d.start()
 	return nil
 }
@@ -212,7 +911,7 @@ func (d *Detector) Stop() {
 	close(d.done)
 	for range d.results {
 		// wait for exit
-		log.Debug("TND dropping result during shutdown")
+		d.logger.Debug("TND dropping result during shutdown")
 	}
 }
 
@@ -224,23 +923,53 @@ func (d *Detector) Probe() {
 	}
 }
 
-// Results returns the results channel.
+// Errors returns the errors channel. It reports errors while the Detector is
+// running, e.g., invalid config files. Errors are dropped if the channel is
+// not read.
+func (d *Detector) Errors() chan error {
+	return d.errors
+}
+
+// Results returns the results channel. Note: only one of Results and
+// DetailedResults should be used to read the results.
 func (d *Detector) Results() chan bool {
 	return d.results
 }
 
+// DetailedResults returns the detailed results channel. Note: only one of
+// Results and DetailedResults should be used to read the results.
+func (d *Detector) DetailedResults() chan *Result {
+	return d.detailedResults
+}
+
 // NewDetector returns a new Detector.
 func NewDetector(config *Config) *Detector {
-	probes := make(chan struct{})
+	routeProbes := make(chan struct{})
+	fileProbes := make(chan struct{})
+	logger := logging.Default()
+	clock := debounce.RealClock
 	return &Detector{
-		config:  config,
-		probes:  probes,
-		results: make(chan bool),
-		done:    make(chan struct{}),
-		dialer:  &net.Dialer{},
-		rw:      routes.NewWatch(probes),
-		fw:      files.NewWatch(probes, config.WatchFiles),
-
-		probeResults: make(chan bool),
+		config:          config,
+		probes:          make(chan struct{}),
+		results:         make(chan bool),
+		detailedResults: make(chan *Result),
+		done:            make(chan struct{}),
+		updates:         make(chan *update),
+		errors:          make(chan error, 1),
+		servers:         []*https.Server{},
+		dialer:          &net.Dialer{},
+		metrics:         nopMetrics{},
+		tracer:          newTracer(nil),
+		logger:          logger,
+		rw:              routes.NewWatch(routeProbes, logger),
+		fw:              files.NewWatch(fileProbes, config.WatchFiles, logger),
+		routeProbes:     routeProbes,
+		fileProbes:      fileProbes,
+		configProbes:    make(chan struct{}),
+		clock:           clock,
+		debouncer: debounce.NewDebouncer(clock, config.DebounceQuiet,
+			config.DebounceMaxDelay),
+
+		probeResults: make(chan *Result),
 	}
 }
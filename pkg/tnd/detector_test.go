@@ -1,26 +1,61 @@
 package tnd
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
 	"errors"
+	"log/slog"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/debounce"
+	"github.com/telekom-mms/tnd/internal/files"
+	"github.com/telekom-mms/tnd/internal/https"
+	"github.com/telekom-mms/tnd/internal/routes"
 )
 
 // testWatcher is a watcher that implements the routes.Watcher and
 // files.Watcher interfaces.
-type testWatcher struct{ err error }
+type testWatcher struct {
+	err    error
+	filter *routes.Filter
+}
+
+func (t *testWatcher) Start() error               { return t.err }
+func (t *testWatcher) Stop()                      {}
+func (t *testWatcher) Probes() chan struct{}      { return nil }
+func (t *testWatcher) SetFilter(f *routes.Filter) { t.filter = f }
 
-func (t *testWatcher) Start() error          { return t.err }
-func (t *testWatcher) Stop()                 {}
-func (t *testWatcher) Probes() chan struct{} { return nil }
+// testHash returns a valid hash of data for testing.
+func testHash(data string) string {
+	hash := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(hash[:])
+}
+
+// testCABundle returns a valid PEM encoded CA bundle for testing.
+func testCABundle() []byte {
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  "CERTIFICATE",
+		Bytes: ts.Certificate().Raw,
+	})
+}
 
 // TestDetectorSetGetServers tests SetServers and GetServers of Detector.
 func TestDetectorSetGetServers(t *testing.T) {
@@ -38,6 +73,69 @@ func TestDetectorSetGetServers(t *testing.T) {
 	if !reflect.DeepEqual(got, want) {
 		t.Errorf("got %v, want %v", got, want)
 	}
+
+	// test invalid hash, server should be rejected
+	tnd.SetServers(map[string]string{url: hs, "https://other": "invalid"})
+	got = tnd.GetServers()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// TestDetectorSetGetTrustedServers tests SetTrustedServers and
+// GetTrustedServers of Detector.
+func TestDetectorSetGetTrustedServers(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+
+	url := "https://test.example.com:442"
+	want := []*Server{
+		{URL: url, Hashes: []string{testHash("hash1"), testHash("hash2")}},
+		{
+			URL:            "https://other.example.com",
+			Hashes:         []string{testHash("hash3")},
+			MatchChain:     true,
+			CABundle:       testCABundle(),
+			VerifyHostname: true,
+		},
+	}
+
+	if err := tnd.SetTrustedServers(want); err != nil {
+		t.Fatal(err)
+	}
+	got := tnd.GetTrustedServers()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// get servers returns first hash
+	wantMap := map[string]string{
+		url:                         testHash("hash1"),
+		"https://other.example.com": testHash("hash3"),
+	}
+	gotMap := tnd.GetServers()
+	if !reflect.DeepEqual(gotMap, wantMap) {
+		t.Errorf("got %v, want %v", gotMap, wantMap)
+	}
+
+	// test modification of returned servers
+	got[0].Hashes[0] = "other"
+	if tnd.GetTrustedServers()[0].Hashes[0] != testHash("hash1") {
+		t.Error("modification of returned servers should not change servers")
+	}
+
+	// test invalid hash, servers should not be changed
+	err := tnd.SetTrustedServers([]*Server{{
+		URL:    "https://invalid.example.com",
+		Hashes: []string{testHash("hash4"), "invalid"},
+	}})
+	var vErr *ValidationError
+	if !errors.As(err, &vErr) || vErr.Field != "Servers[0].Hashes[1]" {
+		t.Errorf("got %v, want validation error", err)
+	}
+	got = tnd.GetTrustedServers()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
 }
 
 // TestDetectorSetGetDialer tests SetDialer and GetDialer of Detector.
@@ -53,6 +151,132 @@ func TestDetectorSetGetDialer(t *testing.T) {
 	}
 }
 
+// TestDetectorSetGetConfig tests SetConfig and GetConfig of Detector.
+func TestDetectorSetGetConfig(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+
+	want := NewConfig()
+	want.TrustedTimer = time.Hour
+	if err := tnd.SetConfig(want); err != nil {
+		t.Fatal(err)
+	}
+	got := tnd.GetConfig()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// test modification of set and returned config
+	want.TrustedTimer = time.Minute
+	got.UntrustedTimer = time.Minute
+	if got := tnd.GetConfig(); got.TrustedTimer != time.Hour ||
+		got.UntrustedTimer != UntrustedTimer {
+		t.Error("modification of config should not change config")
+	}
+
+	// test invalid config, config should not be changed
+	invalid := NewConfig()
+	invalid.HTTPSTimeout = 0
+	var err *ValidationError
+	if !errors.As(tnd.SetConfig(invalid), &err) || err.Field != "HTTPSTimeout" {
+		t.Errorf("got %v, want validation error", err)
+	}
+	if got := tnd.GetConfig(); got.HTTPSTimeout != HTTPSTimeout {
+		t.Errorf("got %v, want %v", got.HTTPSTimeout, HTTPSTimeout)
+	}
+}
+
+// TestDetectorSetConfigWatchFiles tests SetConfig of Detector with new watch
+// files before Start().
+func TestDetectorSetConfigWatchFiles(t *testing.T) {
+	defer func(f func(chan struct{}, []string, Logger) files.Watcher) {
+		filesNewWatch = f
+	}(filesNewWatch)
+
+	var watched []string
+	filesNewWatch = func(_ chan struct{}, watchFiles []string, _ Logger) files.Watcher {
+		watched = watchFiles
+		return &testWatcher{}
+	}
+
+	// test unchanged watch files, file watch should be kept
+	tnd := NewDetector(NewConfig())
+	fw := tnd.fw
+	if err := tnd.SetConfig(NewConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if tnd.fw != fw {
+		t.Error("file watch should not be recreated")
+	}
+
+	// test new watch files
+	c := NewConfig()
+	c.WatchFiles = []string{"/test/resolv.conf"}
+	if err := tnd.SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	if tnd.fw == fw || !reflect.DeepEqual(watched, c.WatchFiles) {
+		t.Errorf("file watch should watch new files, got %v", watched)
+	}
+}
+
+// TestDetectorTrustQuorumServers tests the TrustQuorum of Detector with
+// less servers than the quorum.
+func TestDetectorTrustQuorumServers(t *testing.T) {
+	c := NewConfig()
+	c.TrustPolicy = TrustPolicyQuorum
+	c.TrustQuorum = 2
+	servers := []*Server{
+		{URL: "https://test.example.com", Hashes: []string{testHash("hash1")}},
+	}
+	isQuorumError := func(e error) bool {
+		var err *ValidationError
+		return errors.As(e, &err) && err.Field == "TrustQuorum"
+	}
+
+	// test SetTrustedServers
+	tnd := NewDetector(c)
+	if err := tnd.SetTrustedServers(servers); !isQuorumError(err) {
+		t.Errorf("got %v, want quorum error", err)
+	}
+	if len(tnd.GetTrustedServers()) != 0 {
+		t.Error("servers should not be changed")
+	}
+
+	// test SetFileConfig
+	f := NewFileConfig()
+	f.Config = c
+	f.Servers = servers
+	if err := tnd.SetFileConfig(f); !isQuorumError(err) {
+		t.Errorf("got %v, want quorum error", err)
+	}
+
+	// test SetServers, servers should be rejected
+	tnd.SetServers(map[string]string{servers[0].URL: servers[0].Hashes[0]})
+	if len(tnd.GetServers()) != 0 {
+		t.Error("servers should not be changed")
+	}
+
+	// test SetConfig, servers set before quorum
+	tnd = NewDetector(NewConfig())
+	tnd.rw = &testWatcher{}
+	tnd.fw = &testWatcher{}
+	if err := tnd.SetTrustedServers(servers); err != nil {
+		t.Fatal(err)
+	}
+	if err := tnd.SetConfig(c); !isQuorumError(err) {
+		t.Errorf("got %v, want quorum error", err)
+	}
+	if tnd.GetConfig().TrustPolicy != TrustPolicyAny {
+		t.Error("config should not be changed")
+	}
+
+	// test Start
+	tnd.config = c
+	if err := tnd.Start(); !isQuorumError(err) {
+		t.Errorf("got %v, want quorum error", err)
+	}
+}
+
 // TestDetectorProbeHelper tests probe of Detector.
 func TestDetectorProbeHelper(t *testing.T) {
 	// start test https server
@@ -64,27 +288,462 @@ func TestDetectorProbeHelper(t *testing.T) {
 	tnd := NewDetector(NewConfig())
 
 	// test untrusted
-	tnd.SetServers(map[string]string{ts.URL: "invalid"})
-	go tnd.probe()
+	tnd.SetServers(map[string]string{ts.URL: testHash("invalid")})
+	go tnd.probe(context.Background(), &Result{Trigger: TriggerManual})
 
 	want := false
-	got := <-tnd.probeResults
+	r := <-tnd.probeResults
+	got := r.Trusted
 	if got != want {
 		t.Errorf("got %t, want %t", got, want)
 	}
+	if r.Server != "" || r.Trigger != TriggerManual || r.Time.IsZero() ||
+		len(r.Servers) != 1 || r.Servers[0].URL != ts.URL ||
+		r.Servers[0].Trusted || r.Servers[0].Error == nil ||
+		r.Servers[0].Reason != ReasonHashMismatch {
+		t.Errorf("invalid untrusted result: %v", r)
+	}
 
 	// test trusted
 	cert := ts.Certificate()
 	sha := sha256.Sum256(cert.Raw)
 	hash := hex.EncodeToString(sha[:])
 	tnd.SetServers(map[string]string{ts.URL: hash})
-	go tnd.probe()
+	go tnd.probe(context.Background(), &Result{Trigger: TriggerTimer})
 
 	want = true
-	got = <-tnd.probeResults
+	r = <-tnd.probeResults
+	got = r.Trusted
 	if got != want {
 		t.Errorf("got %t, want %t", got, want)
 	}
+	if r.Server != ts.URL || r.Trigger != TriggerTimer || r.Time.IsZero() ||
+		len(r.Servers) != 1 || r.Servers[0].Fingerprint != hash ||
+		!r.Servers[0].Trusted || r.Servers[0].Error != nil {
+		t.Errorf("invalid trusted result: %v", r)
+	}
+
+	// test trusted with public key pin
+	sha = sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	pin := PinPrefix + base64.StdEncoding.EncodeToString(sha[:])
+	tnd.SetServers(map[string]string{ts.URL: pin})
+	go tnd.probe(context.Background(), &Result{Trigger: TriggerTimer})
+
+	r = <-tnd.probeResults
+	if !r.Trusted || r.Servers[0].Fingerprint != pin {
+		t.Errorf("invalid trusted result: %v", r)
+	}
+
+	// test trusted with multiple hashes
+	if err := tnd.SetTrustedServers([]*Server{{
+		URL:    ts.URL,
+		Hashes: []string{testHash("next"), hash},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	go tnd.probe(context.Background(), &Result{Trigger: TriggerTimer})
+
+	r = <-tnd.probeResults
+	if !r.Trusted || r.Servers[0].Fingerprint != hash {
+		t.Errorf("invalid trusted result: %v", r)
+	}
+}
+
+// TestDetectorProbeTrustPolicy tests probe of Detector with trust policies.
+func TestDetectorProbeTrustPolicy(t *testing.T) {
+	// start test https servers
+	ts1 := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts1.Close()
+	ts2 := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts2.Close()
+
+	// get hashes
+	getHash := func(ts *httptest.Server) string {
+		sha := sha256.Sum256(ts.Certificate().Raw)
+		return hex.EncodeToString(sha[:])
+	}
+	hash1 := getHash(ts1)
+	hash2 := getHash(ts2)
+	invalid := testHash("invalid")
+
+	for i, test := range []struct {
+		policy  TrustPolicy
+		quorum  int
+		servers map[string]string
+		trusted bool
+		checks  int
+	}{
+		// one of two servers trusted
+		{TrustPolicyAny, 0, map[string]string{ts1.URL: hash1, ts2.URL: invalid}, true, 0},
+		{TrustPolicyAll, 0, map[string]string{ts1.URL: hash1, ts2.URL: invalid}, false, 0},
+		{TrustPolicyQuorum, 1, map[string]string{ts1.URL: hash1, ts2.URL: invalid}, true, 0},
+		{TrustPolicyQuorum, 2, map[string]string{ts1.URL: hash1, ts2.URL: invalid}, false, 0},
+
+		// both servers trusted
+		{TrustPolicyAny, 0, map[string]string{ts1.URL: hash1, ts2.URL: hash2}, true, 1},
+		{TrustPolicyAll, 0, map[string]string{ts1.URL: hash1, ts2.URL: hash2}, true, 2},
+		{TrustPolicyQuorum, 2, map[string]string{ts1.URL: hash1, ts2.URL: hash2}, true, 2},
+		{TrustPolicyQuorum, 3, map[string]string{ts1.URL: hash1, ts2.URL: hash2}, false, 1},
+
+		// no server trusted, stop early
+		{TrustPolicyAny, 0, map[string]string{ts1.URL: invalid, ts2.URL: invalid}, false, 2},
+		{TrustPolicyAll, 0, map[string]string{ts1.URL: invalid, ts2.URL: invalid}, false, 1},
+		{TrustPolicyQuorum, 2, map[string]string{ts1.URL: invalid, ts2.URL: invalid}, false, 1},
+
+		// no servers
+		{TrustPolicyAll, 0, map[string]string{}, false, 0},
+	} {
+		for _, parallel := range []bool{false, true} {
+			c := NewConfig()
+			c.WaitCheck = 0
+			c.TrustPolicy = test.policy
+			c.TrustQuorum = test.quorum
+			c.ParallelProbes = parallel
+			tnd := NewDetector(NewConfig())
+			tnd.SetServers(test.servers)
+			// set config directly, SetConfig rejects a quorum
+			// greater than the number of servers
+			tnd.config = c
+			go tnd.probe(context.Background(), &Result{Trigger: TriggerManual})
+
+			r := <-tnd.probeResults
+			if r.Trusted != test.trusted {
+				t.Errorf("%d, %t: got %t, want %t",
+					i, parallel, r.Trusted, test.trusted)
+			}
+			if r.Trusted == (r.Server == "") {
+				t.Errorf("%d, %t: invalid server: %s",
+					i, parallel, r.Server)
+			}
+			if test.checks != 0 && len(r.Servers) != test.checks {
+				t.Errorf("%d, %t: got %d checks, want %d",
+					i, parallel, len(r.Servers), test.checks)
+			}
+		}
+	}
+}
+
+// listenSilent starts a test server that accepts connections but never
+// responds. It returns the listener and a channel that receives a value
+// for each accepted connection.
+func listenSilent(t *testing.T) (net.Listener, <-chan struct{}) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = l.Close() })
+	accepted := make(chan struct{}, 16)
+	go func() {
+		conns := []net.Conn{}
+		defer func() {
+			for _, c := range conns {
+				_ = c.Close()
+			}
+		}()
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conns = append(conns, c)
+			select {
+			case accepted <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return l, accepted
+}
+
+// TestDetectorProbeParallelTimeout tests probe of Detector with parallel
+// probes and probe timeout.
+func TestDetectorProbeParallelTimeout(t *testing.T) {
+	// start test server that accepts connections but never responds
+	l, _ := listenSilent(t)
+
+	// create detector
+	c := NewConfig()
+	c.WaitCheck = 0
+	c.HTTPSTimeout = time.Minute
+	c.ProbeTimeout = 100 * time.Millisecond
+	c.ParallelProbes = true
+	tnd := NewDetector(c)
+	tnd.SetServers(map[string]string{
+		"https://" + l.Addr().String() + "/1": testHash("invalid"),
+		"https://" + l.Addr().String() + "/2": testHash("invalid"),
+	})
+	m := &testMetrics{}
+	tnd.SetMetrics(m)
+
+	// probe
+	start := time.Now()
+	go tnd.probe(context.Background(), &Result{Trigger: TriggerManual})
+	r := <-tnd.probeResults
+	if time.Since(start) > 10*time.Second {
+		t.Errorf("probe timeout not applied")
+	}
+	if r.Trusted || len(r.Servers) != 2 {
+		t.Errorf("got %v, want untrusted result with two servers", r)
+	}
+	for _, s := range r.Servers {
+		if s.Reason != ReasonTimeout {
+			t.Errorf("got %s, want %s", s.Reason, ReasonTimeout)
+		}
+	}
+
+	// expired probe timeouts should be recorded in the metrics
+	if len(m.checked) != 2 {
+		t.Errorf("got %d checked servers, want 2", len(m.checked))
+	}
+}
+
+// TestDetectorProbeCancel tests probe of Detector with canceled context.
+func TestDetectorProbeCancel(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		// create detector with long wait time
+		c := NewConfig()
+		c.WaitCheck = time.Minute
+		c.ParallelProbes = parallel
+		tnd := NewDetector(c)
+		tnd.SetServers(map[string]string{"https://127.0.0.1:1": testHash("invalid")})
+
+		// cancel probe, result must be dropped
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			tnd.probe(ctx, &Result{Trigger: TriggerRoute})
+			close(done)
+		}()
+		cancel()
+
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%t: probe not canceled", parallel)
+		}
+		select {
+		case r := <-tnd.probeResults:
+			t.Errorf("%t: got unexpected result %v", parallel, r)
+		default:
+		}
+	}
+}
+
+// TestDetectorProbeCancelMetrics tests the metrics of a probe of Detector
+// that is canceled during the server check.
+func TestDetectorProbeCancelMetrics(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		// start test server that accepts connections but never responds
+		l, accepted := listenSilent(t)
+
+		// create detector
+		c := NewConfig()
+		c.WaitCheck = 0
+		c.HTTPSTimeout = time.Minute
+		c.ParallelProbes = parallel
+		tnd := NewDetector(c)
+		tnd.SetServers(map[string]string{
+			"https://" + l.Addr().String(): testHash("invalid"),
+		})
+		m := &testMetrics{}
+		tnd.SetMetrics(m)
+
+		// cancel probe during server check
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			tnd.probe(ctx, &Result{Trigger: TriggerRoute})
+			close(done)
+		}()
+		<-accepted
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(10 * time.Second):
+			t.Fatalf("%t: probe not canceled", parallel)
+		}
+
+		// canceled check should not be recorded in the metrics
+		m.mu.Lock()
+		if len(m.started) != 1 || len(m.checked) != 0 {
+			t.Errorf("%t: invalid metrics: %v %v",
+				parallel, m.started, m.checked)
+		}
+		m.mu.Unlock()
+	}
+}
+
+// TestDetectorCheck tests Check of Detector.
+func TestDetectorCheck(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	sha := sha256.Sum256(ts.Certificate().Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	// create detector, not started
+	c := NewConfig()
+	c.WaitCheck = 0
+	c.TrustPolicy = TrustPolicyAll
+	tnd := NewDetector(c)
+
+	// test trusted
+	tnd.SetServers(map[string]string{ts.URL: hash})
+	r, err := tnd.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Trusted || r.Server != ts.URL || r.Trigger != TriggerManual ||
+		r.Time.IsZero() || len(r.Servers) != 1 {
+		t.Errorf("got %v, want trusted result", r)
+	}
+
+	// test untrusted with trust policy
+	tnd.SetServers(map[string]string{
+		ts.URL:                hash,
+		"https://127.0.0.1:1": testHash("invalid"),
+	})
+	r, err = tnd.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Trusted || r.Server != "" {
+		t.Errorf("got %v, want untrusted result", r)
+	}
+
+	// test canceled context
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r, err := tnd.Check(ctx); r.Trusted || !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, %v, want canceled untrusted result", r, err)
+	}
+}
+
+// testMetrics records the metrics of a Detector.
+type testMetrics struct {
+	mu       sync.Mutex
+	started  []Trigger
+	checked  []*ServerResult
+	finished []*Result
+}
+
+func (m *testMetrics) ProbeStarted(trigger Trigger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.started = append(m.started, trigger)
+}
+
+func (m *testMetrics) ServerChecked(r *ServerResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.checked = append(m.checked, r)
+}
+
+func (m *testMetrics) ProbeFinished(r *Result) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.finished = append(m.finished, r)
+}
+
+// TestDetectorSetMetrics tests SetMetrics of Detector.
+func TestDetectorSetMetrics(t *testing.T) {
+	// create detector, not started
+	c := NewConfig()
+	c.WaitCheck = 0
+	tnd := NewDetector(c)
+	tnd.SetServers(map[string]string{"https://127.0.0.1:1": testHash("invalid")})
+
+	// test check
+	m := &testMetrics{}
+	tnd.SetMetrics(m)
+	r, err := tnd.Check(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.started) != 1 || m.started[0] != TriggerManual ||
+		len(m.checked) != 1 || m.checked[0].Reason != ReasonRefused ||
+		len(m.finished) != 1 || m.finished[0] != r {
+		t.Errorf("invalid metrics: %v %v %v", m.started, m.checked, m.finished)
+	}
+
+	// test canceled check
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = tnd.Check(ctx)
+	if len(m.started) != 2 || len(m.finished) != 1 {
+		t.Errorf("invalid metrics: %v %v", m.started, m.finished)
+	}
+
+	// test probe result, drop stale result
+	tnd.timer = time.NewTimer(0)
+	go func() {
+		for range tnd.results {
+		}
+	}()
+	tnd.running = true
+	tnd.probing = &Result{Trusted: true}
+	tnd.handleProbeResult(tnd.probing)
+	tnd.handleProbeResult(&Result{})
+	close(tnd.results)
+	if len(m.finished) != 2 || !m.finished[1].Trusted {
+		t.Errorf("invalid metrics: %v", m.finished)
+	}
+
+	// test disabled metrics
+	tnd.SetMetrics(nil)
+	if _, err := tnd.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(m.started) != 2 {
+		t.Errorf("invalid metrics: %v", m.started)
+	}
+}
+
+// TestDetectorSetLogger tests SetLogger of Detector.
+func TestDetectorSetLogger(t *testing.T) {
+	// create detector, not started
+	c := NewConfig()
+	c.WaitCheck = 0
+	tnd := NewDetector(c)
+	rw, fw := tnd.rw, tnd.fw
+
+	// test slog logger
+	b := &bytes.Buffer{}
+	tnd.SetLogger(NewSlogLogger(slog.NewJSONHandler(b, &slog.HandlerOptions{
+		Level: slog.LevelDebug,
+	})))
+	if tnd.rw == rw || tnd.fw == fw {
+		t.Error("watchers should use new logger")
+	}
+	tnd.SetServers(map[string]string{"https://127.0.0.1:1": testHash("invalid")})
+	if _, err := tnd.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	messages := map[string]map[string]any{}
+	for _, line := range bytes.Split(bytes.TrimSpace(b.Bytes()), []byte("\n")) {
+		m := map[string]any{}
+		if err := json.Unmarshal(line, &m); err != nil {
+			t.Fatal(err)
+		}
+		messages[m["msg"].(string)] = m
+	}
+	if m := messages["TND https server not trusted"]; m[FieldURL] != "https://127.0.0.1:1" ||
+		m[FieldReason] != "refused" || m[FieldError] == nil {
+		t.Errorf("invalid server log message: %v", m)
+	}
+	if m := messages["TND http HEAD request error"]; m[FieldURL] != "https://127.0.0.1:1" {
+		t.Errorf("invalid check log message: %v", m)
+	}
+
+	// test default logger
+	tnd.SetLogger(nil)
+	if tnd.logger == nil {
+		t.Error("logger should not be nil")
+	}
 }
 
 // TestDetectorHandleProbeRequest tests handleProbeRequest of Detector.
@@ -92,19 +751,27 @@ func TestDetectorHandleProbeRequest(t *testing.T) {
 	// create detector
 	tnd := NewDetector(NewConfig())
 
-	// already running
-	tnd.running = true
-	tnd.handleProbeRequest()
-	if tnd.runAgain != true {
-		t.Error("run again should be true")
+	// not runnnig
+	tnd.handleProbeRequest(TriggerFile)
+	if tnd.running != true {
+		t.Error("running should be true")
+	}
+	if tnd.probing.Trigger != TriggerFile {
+		t.Errorf("got %v, want %v", tnd.probing.Trigger, TriggerFile)
 	}
 
-	// not runnnig
-	tnd.running = false
-	tnd.handleProbeRequest()
+	// already running, running probe should be replaced
+	old := tnd.probing
+	tnd.handleProbeRequest(TriggerRoute)
 	if tnd.running != true {
 		t.Error("running should be true")
 	}
+	if tnd.probing == old {
+		t.Error("running probe should be replaced")
+	}
+	if tnd.probing.Trigger != TriggerRoute {
+		t.Errorf("got %v, want %v", tnd.probing.Trigger, TriggerRoute)
+	}
 
 	close(tnd.done)
 }
@@ -126,29 +793,84 @@ func TestDetectorHandleProbeResult(t *testing.T) {
 
 	// test not trusted
 	tnd.running = true
-	tnd.handleProbeResult(false)
+	tnd.probing = &Result{Trusted: false}
+	tnd.handleProbeResult(tnd.probing)
 	if tnd.running != false {
 		t.Error("running should be false")
 	}
 
 	// test trusted
 	tnd.running = true
-	tnd.handleProbeResult(true)
+	tnd.probing = &Result{Trusted: true}
+	tnd.handleProbeResult(tnd.probing)
 	if tnd.running != false {
 		t.Error("running should be false")
 	}
+	if tnd.trusted != true {
+		t.Error("trusted should be true")
+	}
 
-	// test with runAgain
+	// test stale result
 	tnd.running = true
-	tnd.runAgain = true
-	tnd.handleProbeResult(false)
-	if tnd.runAgain != false {
-		t.Error("runAgain should be false")
+	tnd.probing = &Result{Trusted: true}
+	tnd.handleProbeResult(&Result{Trusted: false})
+	if tnd.running != true {
+		t.Error("running should be true")
+	}
+	if tnd.trusted != true {
+		t.Error("trusted should be true")
+	}
+
+	// test stale result, not running
+	tnd.running = false
+	tnd.probing = nil
+	tnd.handleProbeResult(&Result{Trusted: false})
+	if tnd.trusted != true {
+		t.Error("trusted should be true")
 	}
 
 	close(tnd.results)
 }
 
+// TestDetectorSendResultUpdate tests updates of Detector while sendResult
+// waits for the results reader.
+func TestDetectorSendResultUpdate(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+	tnd.fw = &testWatcher{}
+	tnd.timer = time.NewTimer(time.Hour)
+	tnd.started = true
+	defer close(tnd.done)
+
+	// send pending result like the main loop
+	sent := make(chan struct{})
+	go func() {
+		tnd.sendResult(&Result{Trusted: true})
+		close(sent)
+	}()
+
+	// update servers from results reader before reading the result
+	servers := map[string]string{"https://test.example.com": testHash("test")}
+	updated := make(chan struct{})
+	go func() {
+		tnd.SetServers(servers)
+		close(updated)
+	}()
+	select {
+	case <-updated:
+	case <-time.After(10 * time.Second):
+		t.Fatal("update should not block on pending result")
+	}
+	if got := tnd.GetServers(); !reflect.DeepEqual(got, servers) {
+		t.Errorf("got %v, want %v", got, servers)
+	}
+
+	// read pending result
+	if r := <-tnd.results; !r {
+		t.Error("result should be trusted")
+	}
+	<-sent
+}
+
 // TestDetectorHandleTimer tests handleTimer of Detector.
 func TestDetectorHandleTimer(t *testing.T) {
 	// create detector
@@ -170,6 +892,503 @@ func TestDetectorHandleTimer(t *testing.T) {
 	}
 }
 
+// TestDetectorHandleEvent tests handleEvent and handleDebounce of Detector.
+func TestDetectorHandleEvent(t *testing.T) {
+	// fired returns whether the debouncer of tnd fired
+	fired := func(tnd *Detector) bool {
+		select {
+		case <-tnd.debouncer.C():
+			return true
+		default:
+			return false
+		}
+	}
+
+	// test without debouncing, the default
+	tnd := NewDetector(NewConfig())
+	tnd.handleEvent(TriggerRoute)
+	if !tnd.running || tnd.probing.Trigger != TriggerRoute {
+		t.Error("event should trigger probe")
+	}
+	close(tnd.done)
+
+	// test burst of events
+	quiet, maxDelay := 500*time.Millisecond, 2*time.Second
+	c := NewConfig()
+	c.DebounceQuiet = quiet
+	c.DebounceMaxDelay = maxDelay
+	clock := debounce.NewFakeClock(time.Now())
+	tnd = NewDetector(c)
+	tnd.clock = clock
+	tnd.debouncer = tnd.newDebouncer()
+	defer close(tnd.done)
+
+	tnd.handleEvent(TriggerFile)
+	for range 3 {
+		clock.Advance(quiet / 2)
+		tnd.handleEvent(TriggerRoute)
+	}
+	if tnd.running || fired(tnd) {
+		t.Fatal("events should not trigger probe during burst")
+	}
+	clock.Advance(quiet)
+	if !fired(tnd) {
+		t.Fatal("debouncer should fire after quiet period")
+	}
+	tnd.handleDebounce()
+	if !tnd.running || tnd.probing.Trigger != TriggerFile {
+		t.Error("burst should trigger probe with first trigger")
+	}
+
+	// test max delay
+	tnd.stopProbe()
+	tnd.handleEvent(TriggerRoute)
+	step := quiet / 2
+	for range maxDelay/step - 1 {
+		clock.Advance(step)
+		if fired(tnd) {
+			t.Fatal("debouncer should not fire before max delay")
+		}
+		tnd.handleEvent(TriggerFile)
+	}
+	clock.Advance(step)
+	if !fired(tnd) {
+		t.Fatal("debouncer should fire after max delay")
+	}
+	tnd.handleDebounce()
+	if !tnd.running || tnd.probing.Trigger != TriggerRoute {
+		t.Error("burst should trigger probe with first trigger")
+	}
+
+	// test update drops pending events
+	tnd.fw = &testWatcher{}
+	tnd.timer = time.NewTimer(time.Hour)
+	tnd.handleEvent(TriggerRoute)
+	c = NewConfig()
+	c.DebounceQuiet = time.Second
+	c.DebounceMaxDelay = time.Second
+	u := &update{config: c, err: make(chan error, 1)}
+	tnd.handleUpdate(u)
+	if err := <-u.err; err != nil {
+		t.Errorf("update should not fail: %v", err)
+	}
+	clock.Advance(time.Minute)
+	if tnd.debouncer.Pending() || fired(tnd) {
+		t.Error("update should drop pending events")
+	}
+}
+
+// TestDetectorHandleUpdate tests handleUpdate of Detector.
+func TestDetectorHandleUpdate(t *testing.T) {
+	defer func(f func(chan struct{}, []string, Logger) files.Watcher) {
+		filesNewWatch = f
+	}(filesNewWatch)
+
+	// create detector
+	tnd := NewDetector(NewConfig())
+	tnd.fw = &testWatcher{}
+	tnd.timer = time.NewTimer(time.Hour)
+	defer close(tnd.done)
+
+	// test update without new watch files
+	fw := tnd.fw
+	c := NewConfig()
+	c.TrustedTimer = time.Hour
+	u := &update{config: c, err: make(chan error, 1)}
+	tnd.handleUpdate(u)
+	if err := <-u.err; err != nil {
+		t.Errorf("update should not fail: %v", err)
+	}
+	if tnd.config != c || tnd.fw != fw {
+		t.Error("config should be updated without restarting file watch")
+	}
+	if !tnd.running || tnd.probing.Trigger != TriggerConfig {
+		t.Error("update should trigger probe")
+	}
+
+	// test update with new route filter
+	rw := &testWatcher{}
+	tnd.rw = rw
+	c = NewConfig()
+	c.RouteTables = []int{254}
+	u = &update{config: c, err: make(chan error, 1)}
+	tnd.handleUpdate(u)
+	if err := <-u.err; err != nil {
+		t.Errorf("update should not fail: %v", err)
+	}
+	if rw.filter == nil || !slices.Equal(rw.filter.Tables, c.RouteTables) {
+		t.Errorf("update should set route filter, got %v", rw.filter)
+	}
+
+	// test update with new watch files
+	filesNewWatch = func(chan struct{}, []string, Logger) files.Watcher {
+		return &testWatcher{}
+	}
+	c = NewConfig()
+	c.WatchFiles = []string{"/test/resolv.conf"}
+	u = &update{config: c, err: make(chan error, 1)}
+	tnd.handleUpdate(u)
+	if err := <-u.err; err != nil {
+		t.Errorf("update should not fail: %v", err)
+	}
+	if tnd.config != c || tnd.fw == fw {
+		t.Error("config should be updated and file watch restarted")
+	}
+
+	// test update with file watch error, config should not be changed
+	filesNewWatch = func(chan struct{}, []string, Logger) files.Watcher {
+		return &testWatcher{err: errors.New("test error")}
+	}
+	old := tnd.config
+	fw = tnd.fw
+	u = &update{config: NewConfig(), err: make(chan error, 1)}
+	tnd.handleUpdate(u)
+	if err := <-u.err; err == nil {
+		t.Error("update should fail")
+	}
+	if tnd.config != old || tnd.fw != fw {
+		t.Error("config and file watch should not be changed")
+	}
+
+	// test update of servers and dialer
+	servers := []*https.Server{{URL: "https://test.example.com"}}
+	dialer := &net.Dialer{}
+	u = &update{servers: servers, dialer: dialer, err: make(chan error, 1)}
+	tnd.handleUpdate(u)
+	if err := <-u.err; err != nil {
+		t.Errorf("update should not fail: %v", err)
+	}
+	if tnd.config != old || !reflect.DeepEqual(tnd.servers, servers) ||
+		tnd.dialer != dialer {
+		t.Error("servers and dialer should be updated")
+	}
+}
+
+// TestDetectorUpdateRunning tests runtime updates of a running Detector.
+func TestDetectorUpdateRunning(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	sha := sha256.Sum256(ts.Certificate().Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	// start detector
+	c := NewConfig()
+	c.WaitCheck = 0
+	tnd := NewDetector(c)
+	tnd.rw = &testWatcher{}
+	tnd.fw = &testWatcher{}
+	if err := tnd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer tnd.Stop()
+
+	// updating servers triggers probe with new servers
+	if err := tnd.SetTrustedServers([]*Server{{
+		URL:    ts.URL,
+		Hashes: []string{hash},
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	r := <-tnd.DetailedResults()
+	if !r.Trusted || r.Trigger != TriggerConfig || r.Server != ts.URL {
+		t.Errorf("got %v, want trusted config result", r)
+	}
+
+	// quorum greater than the number of servers is rejected
+	c = NewConfig()
+	c.WaitCheck = 0
+	c.TrustPolicy = TrustPolicyQuorum
+	c.TrustQuorum = 2
+	var err *ValidationError
+	if !errors.As(tnd.SetConfig(c), &err) || err.Field != "TrustQuorum" {
+		t.Errorf("got %v, want validation error", err)
+	}
+
+	// updating config triggers probe
+	c.TrustPolicy = TrustPolicyAll
+	c.TrustQuorum = 0
+	if err := tnd.SetConfig(c); err != nil {
+		t.Fatal(err)
+	}
+	r = <-tnd.DetailedResults()
+	if !r.Trusted || r.Trigger != TriggerConfig {
+		t.Errorf("got %v, want trusted config result", r)
+	}
+	if got := tnd.GetConfig(); !reflect.DeepEqual(got, c) {
+		t.Errorf("got %v, want %v", got, c)
+	}
+}
+
+// TestDetectorUpdateRace tests races between runtime updates and in-flight
+// probes of a running Detector.
+func TestDetectorUpdateRace(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	sha := sha256.Sum256(ts.Certificate().Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	// start detector
+	c := NewConfig()
+	c.WaitCheck = 0
+	tnd := NewDetector(c)
+	tnd.SetServers(map[string]string{ts.URL: hash})
+	tnd.rw = &testWatcher{}
+	tnd.fw = &testWatcher{}
+	if err := tnd.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	// read results
+	results := make(chan struct{})
+	go func() {
+		defer close(results)
+		for r := range tnd.DetailedResults() {
+			if r.Trusted && r.Server != ts.URL {
+				t.Errorf("invalid trusted result: %v", r)
+			}
+		}
+	}()
+
+	// update and probe concurrently
+	done := make(chan struct{})
+	for i := range 4 {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := range 20 {
+				switch (i + j) % 5 {
+				case 0:
+					c := NewConfig()
+					c.WaitCheck = 0
+					c.ParallelProbes = j%2 == 0
+					if err := tnd.SetConfig(c); err != nil {
+						t.Error(err)
+					}
+				case 1:
+					tnd.SetServers(map[string]string{ts.URL: hash})
+				case 2:
+					if err := tnd.SetTrustedServers([]*Server{{
+						URL:    ts.URL,
+						Hashes: []string{testHash("other"), hash},
+					}}); err != nil {
+						t.Error(err)
+					}
+				case 3:
+					tnd.SetDialer(&net.Dialer{})
+				case 4:
+					tnd.Probe()
+				}
+				_ = tnd.GetConfig()
+				_ = tnd.GetTrustedServers()
+				_ = tnd.GetDialer()
+			}
+		}()
+	}
+	for range 4 {
+		<-done
+	}
+
+	// stop detector
+	tnd.Stop()
+	<-results
+}
+
+// TestDetectorSetFileConfig tests SetFileConfig of Detector.
+func TestDetectorSetFileConfig(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+
+	f := NewFileConfig()
+	f.Config.TrustedTimer = time.Hour
+	f.Servers = []*Server{{
+		URL:    "https://test.example.com",
+		Hashes: []string{testHash("hash1")},
+	}}
+	f.Dialer = &net.Dialer{Timeout: time.Second}
+	if err := tnd.SetFileConfig(f); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(tnd.GetConfig(), f.Config) ||
+		!reflect.DeepEqual(tnd.GetTrustedServers(), f.Servers) ||
+		tnd.GetDialer() != f.Dialer {
+		t.Error("file config should be set")
+	}
+
+	// test invalid, nothing should be changed
+	for field, invalid := range map[string]*FileConfig{
+		"FileConfig":           nil,
+		"Config":               {Servers: f.Servers},
+		"HTTPSTimeout":         {Config: &Config{WatchFiles: WatchFiles}},
+		"Servers[0].Hashes[0]": {Config: NewConfig(), Servers: []*Server{{URL: "https://test", Hashes: []string{"invalid"}}}},
+	} {
+		var err *ValidationError
+		if e := tnd.SetFileConfig(invalid); !errors.As(e, &err) || err.Field != field {
+			t.Errorf("got %v, want error in %s", e, field)
+		}
+		if !reflect.DeepEqual(tnd.GetConfig(), f.Config) ||
+			!reflect.DeepEqual(tnd.GetTrustedServers(), f.Servers) ||
+			tnd.GetDialer() != f.Dialer {
+			t.Error("file config should not be changed")
+		}
+	}
+}
+
+// TestDetectorSetConfigFile tests SetConfigFile of Detector.
+func TestDetectorSetConfigFile(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+	tnd.SetConfigFile("/test/../test/tnd.json")
+	if tnd.configFile != "/test/tnd.json" {
+		t.Errorf("got %s, want /test/tnd.json", tnd.configFile)
+	}
+
+	// test relative path
+	tnd.SetConfigFile("tnd.json")
+	if !filepath.IsAbs(tnd.configFile) {
+		t.Errorf("got %s, want absolute path", tnd.configFile)
+	}
+}
+
+// TestDetectorHandleConfigFile tests handleConfigFile of Detector.
+func TestDetectorHandleConfigFile(t *testing.T) {
+	// create detector
+	tnd := NewDetector(NewConfig())
+	tnd.fw = &testWatcher{}
+	tnd.timer = time.NewTimer(time.Hour)
+	defer close(tnd.done)
+
+	// test config file without servers, config should not be changed
+	tnd.SetConfigFile(writeConfigFile(t, `{"trusted_timer": "1h"}`))
+	tnd.handleConfigFile()
+	var err *ValidationError
+	if !errors.As(<-tnd.errors, &err) || err.Field != "Servers" {
+		t.Errorf("got %v, want validation error", err)
+	}
+	if tnd.running || tnd.config.TrustedTimer == time.Hour {
+		t.Error("config file without servers should not be applied")
+	}
+
+	// test changed config file
+	hash := testHash("hash1")
+	path := writeConfigFile(t, `{
+		"trusted_timer": "1h",
+		"servers": [{"url": "https://test.example.com", "hashes": ["`+hash+`"]}],
+		"dialer": {"timeout": "1s"}
+	}`)
+	tnd.SetConfigFile(path)
+	tnd.handleConfigFile()
+	if !tnd.running || tnd.probing.Trigger != TriggerConfig {
+		t.Error("changed config file should trigger probe")
+	}
+	if tnd.config.TrustedTimer != time.Hour ||
+		len(tnd.servers) != 1 || tnd.servers[0].Hashes[0] != hash ||
+		tnd.dialer.Timeout != time.Second {
+		t.Error("config file should be applied")
+	}
+	select {
+	case err := <-tnd.errors:
+		t.Errorf("got unexpected error: %v", err)
+	default:
+	}
+
+	// test unchanged config file, no probe
+	tnd.stopProbe()
+	tnd.handleConfigFile()
+	if tnd.running {
+		t.Error("unchanged config file should not trigger probe")
+	}
+
+	// test config file without dialer, dialer should not be changed
+	dialer := &net.Dialer{Timeout: 2 * time.Second}
+	tnd.SetDialer(dialer)
+	if err := os.WriteFile(path, []byte(`{
+		"trusted_timer": "2h",
+		"servers": [{"url": "https://test.example.com", "hashes": ["`+hash+`"]}]
+	}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tnd.handleConfigFile()
+	if tnd.config.TrustedTimer != 2*time.Hour || tnd.dialer != dialer {
+		t.Errorf("got %v, %v, want dialer to be kept", tnd.config, tnd.dialer)
+	}
+
+	// test invalid config file, config should not be changed
+	config, servers, dialer := tnd.config, tnd.servers, tnd.dialer
+	if err := os.WriteFile(path, []byte(`{"trusted_timer": "0s"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tnd.handleConfigFile()
+	if !errors.As(<-tnd.errors, &err) || err.Field != "TrustedTimer" {
+		t.Errorf("got %v, want validation error", err)
+	}
+	if tnd.config != config || !reflect.DeepEqual(tnd.servers, servers) ||
+		tnd.dialer != dialer {
+		t.Error("config should not be changed")
+	}
+
+	// test missing config file
+	tnd.SetConfigFile(filepath.Join(t.TempDir(), "missing.json"))
+	tnd.handleConfigFile()
+	if err := <-tnd.errors; !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want not exist error", err)
+	}
+}
+
+// TestDetectorConfigFileRunning tests reloading the config file of a
+// running Detector.
+func TestDetectorConfigFileRunning(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	sha := sha256.Sum256(ts.Certificate().Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	// start detector with config file
+	path := writeConfigFile(t, `{"wait_check": "0s"}`)
+	c := NewConfig()
+	c.WaitCheck = 0
+	tnd := NewDetector(c)
+	tnd.SetConfigFile(path)
+	tnd.rw = &testWatcher{}
+	tnd.fw = &testWatcher{}
+	if err := tnd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer tnd.Stop()
+
+	// replace config file with new servers
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(`{
+		"wait_check": "0s",
+		"servers": [{"url": "`+ts.URL+`", "hashes": ["`+hash+`"]}]
+	}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	r := <-tnd.DetailedResults()
+	if !r.Trusted || r.Trigger != TriggerConfig || r.Server != ts.URL {
+		t.Errorf("got %v, want trusted config result", r)
+	}
+
+	// write invalid config file
+	if err := os.WriteFile(path, []byte(`{"unknown": true}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-tnd.Errors(); err == nil {
+		t.Error("invalid config file should return error")
+	}
+	if got := tnd.GetServers(); !reflect.DeepEqual(got, map[string]string{ts.URL: hash}) {
+		t.Errorf("servers should not be changed, got %v", got)
+	}
+}
+
 // TestDetectorStartStop tests Start and Stop of Detector.
 func TestDetectorStartStop(t *testing.T) {
 	// test rw error
@@ -194,16 +1413,47 @@ func TestDetectorStartStop(t *testing.T) {
 		}
 	})
 
-	// test without errors
-	t.Run("no errors", func(t *testing.T) {
+	// test cw error
+	t.Run("config file watch error", func(t *testing.T) {
 		tnd := NewDetector(NewConfig())
+		tnd.SetConfigFile(filepath.Join(t.TempDir(), "missing", "tnd.json"))
+		tnd.rw = &testWatcher{}
+		tnd.fw = &testWatcher{}
+		if err := tnd.Start(); err == nil {
+			t.Error("start should fail")
+			return
+		}
+	})
+
+	// test invalid config
+	t.Run("invalid config", func(t *testing.T) {
+		c := NewConfig()
+		c.HTTPSTimeout = 0
+		tnd := NewDetector(c)
 		tnd.rw = &testWatcher{}
 		tnd.fw = &testWatcher{}
+		var err *ValidationError
+		if !errors.As(tnd.Start(), &err) || err.Field != "HTTPSTimeout" {
+			t.Errorf("start should fail with validation error, got %v", err)
+		}
+	})
+
+	// test without errors
+	t.Run("no errors", func(t *testing.T) {
+		c := NewConfig()
+		c.RouteDefaultOnly = true
+		tnd := NewDetector(c)
+		rw := &testWatcher{}
+		tnd.rw = rw
+		tnd.fw = &testWatcher{}
 		if err := tnd.Start(); err != nil {
 			t.Errorf("start should not fail: %v", err)
 			return
 		}
 		tnd.Stop()
+		if rw.filter == nil || !rw.filter.DefaultOnly {
+			t.Errorf("start should set route filter, got %v", rw.filter)
+		}
 	})
 }
 
@@ -224,6 +1474,22 @@ func TestDetectorProbe(t *testing.T) {
 	tnd.Stop()
 }
 
+// TestDetectorProbeDetailed tests Probe of Detector with detailed results.
+func TestDetectorProbeDetailed(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+	tnd.rw = &testWatcher{}
+	tnd.fw = &testWatcher{}
+	if err := tnd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	tnd.Probe()
+	r := <-tnd.DetailedResults()
+	if r.Trusted || r.Trigger != TriggerManual {
+		t.Errorf("got %v, want untrusted manual result", r)
+	}
+	tnd.Stop()
+}
+
 // TestDetectorResults tests Results of Detector.
 func TestDetectorResults(t *testing.T) {
 	tnd := NewDetector(NewConfig())
@@ -234,6 +1500,26 @@ func TestDetectorResults(t *testing.T) {
 	}
 }
 
+// TestDetectorDetailedResults tests DetailedResults of Detector.
+func TestDetectorDetailedResults(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+	want := tnd.detailedResults
+	got := tnd.DetailedResults()
+	if want != got {
+		t.Errorf("got %p, want %p", got, want)
+	}
+}
+
+// TestDetectorErrors tests Errors of Detector.
+func TestDetectorErrors(t *testing.T) {
+	tnd := NewDetector(NewConfig())
+	want := tnd.errors
+	got := tnd.Errors()
+	if want != got {
+		t.Errorf("got %p, want %p", got, want)
+	}
+}
+
 // TestNewDetector tests NewDetector.
 func TestNewDetector(t *testing.T) {
 	c := NewConfig()
@@ -246,10 +1532,16 @@ func TestNewDetector(t *testing.T) {
 	for i, x := range []any{
 		tnd.probes,
 		tnd.results,
+		tnd.detailedResults,
 		tnd.done,
+		tnd.updates,
+		tnd.errors,
 		tnd.dialer,
 		tnd.rw,
 		tnd.fw,
+		tnd.routeProbes,
+		tnd.fileProbes,
+		tnd.configProbes,
 		tnd.probeResults,
 	} {
 		if x == nil {
@@ -0,0 +1,238 @@
+package tnd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// envVar is an environment variable that overrides a setting of a
+// FileConfig.
+type envVar struct {
+	// name is the name of the environment variable
+	name string
+
+	// field is the name of the Config field set by the variable, if any
+	field string
+
+	// set parses value and sets it in f
+	set func(f *FileConfig, value string) error
+}
+
+// setDuration returns an envVar set function for the duration d.
+func setDuration(d func(f *FileConfig) *time.Duration) func(*FileConfig, string) error {
+	return func(f *FileConfig, value string) error {
+		v, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d(f) = v
+		return nil
+	}
+}
+
+// setInts returns an envVar set function for the comma-separated integers
+// in i.
+func setInts(i func(f *FileConfig) *[]int) func(*FileConfig, string) error {
+	return func(f *FileConfig, value string) error {
+		var v []int
+		for _, s := range strings.Split(value, ",") {
+			if s == "" {
+				continue
+			}
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+			v = append(v, n)
+		}
+		*i(f) = v
+		return nil
+	}
+}
+
+// envVars are the environment variables in the order they are applied.
+var envVars = []envVar{
+	{"TND_WATCH_FILES", "WatchFiles", func(f *FileConfig, value string) error {
+		f.Config.WatchFiles = nil
+		for _, file := range strings.Split(value, ",") {
+			if file != "" {
+				f.Config.WatchFiles = append(f.Config.WatchFiles, file)
+			}
+		}
+		return nil
+	}},
+	{"TND_WAIT_CHECK", "WaitCheck", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.WaitCheck
+	})},
+	{"TND_HTTPS_TIMEOUT", "HTTPSTimeout", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.HTTPSTimeout
+	})},
+	{"TND_UNTRUSTED_TIMER", "UntrustedTimer", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.UntrustedTimer
+	})},
+	{"TND_TRUSTED_TIMER", "TrustedTimer", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.TrustedTimer
+	})},
+	{"TND_TRUST_POLICY", "TrustPolicy", func(f *FileConfig, value string) error {
+		return f.Config.TrustPolicy.UnmarshalText([]byte(value))
+	}},
+	{"TND_TRUST_QUORUM", "TrustQuorum", func(f *FileConfig, value string) error {
+		v, err := strconv.Atoi(value)
+		if err != nil {
+			return err
+		}
+		f.Config.TrustQuorum = v
+		return nil
+	}},
+	{"TND_PARALLEL_PROBES", "ParallelProbes", func(f *FileConfig, value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.Config.ParallelProbes = v
+		return nil
+	}},
+	{"TND_PROBE_TIMEOUT", "ProbeTimeout", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.ProbeTimeout
+	})},
+	{"TND_DEBOUNCE_QUIET", "DebounceQuiet", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.DebounceQuiet
+	})},
+	{"TND_DEBOUNCE_MAX_DELAY", "DebounceMaxDelay", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Config.DebounceMaxDelay
+	})},
+	{"TND_ROUTE_TABLES", "RouteTables", setInts(func(f *FileConfig) *[]int {
+		return &f.Config.RouteTables
+	})},
+	{"TND_ROUTE_PROTOCOLS", "RouteProtocols", setInts(func(f *FileConfig) *[]int {
+		return &f.Config.RouteProtocols
+	})},
+	{"TND_ROUTE_IGNORE_INTERFACES", "RouteIgnoreInterfaces", func(f *FileConfig, value string) error {
+		f.Config.RouteIgnoreInterfaces = nil
+		for _, iface := range strings.Split(value, ",") {
+			if iface != "" {
+				f.Config.RouteIgnoreInterfaces = append(
+					f.Config.RouteIgnoreInterfaces, iface)
+			}
+		}
+		return nil
+	}},
+	{"TND_ROUTE_DEFAULT_ONLY", "RouteDefaultOnly", func(f *FileConfig, value string) error {
+		v, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		f.Config.RouteDefaultOnly = v
+		return nil
+	}},
+	{"TND_ROUTE_FAMILIES", "RouteFamilies", setInts(func(f *FileConfig) *[]int {
+		return &f.Config.RouteFamilies
+	})},
+	{"TND_SERVERS", "", func(f *FileConfig, value string) error {
+		servers, err := ParseServers(value)
+		if err != nil {
+			return err
+		}
+		f.Servers = servers
+		return nil
+	}},
+	{"TND_DIALER_TIMEOUT", "", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Dialer.Timeout
+	})},
+	{"TND_DIALER_KEEP_ALIVE", "", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Dialer.KeepAlive
+	})},
+	{"TND_DIALER_FALLBACK_DELAY", "", setDuration(func(f *FileConfig) *time.Duration {
+		return &f.Dialer.FallbackDelay
+	})},
+	{"TND_DIALER_LOCAL_ADDRESS", "", func(f *FileConfig, value string) error {
+		ip := net.ParseIP(value)
+		if ip == nil {
+			return fmt.Errorf("invalid ip address %q", value)
+		}
+		f.Dialer.LocalAddr = &net.TCPAddr{IP: ip}
+		return nil
+	}},
+}
+
+// NewFileConfig returns a new FileConfig with the default Config of
+// NewConfig, no servers and the default dialer.
+func NewFileConfig() *FileConfig {
+	return &FileConfig{
+		Config:  NewConfig(),
+		Servers: []*Server{},
+		Dialer:  &net.Dialer{},
+	}
+}
+
+// LoadEnv overrides the settings in f with the TND environment variables.
+// The environment variables are named after the config file settings, see
+// LoadConfig, in upper case with prefix "TND_", e.g., TND_WAIT_CHECK,
+// TND_HTTPS_TIMEOUT, TND_TRUSTED_TIMER or TND_DIALER_TIMEOUT. Lists, e.g.,
+// TND_WATCH_FILES, TND_ROUTE_TABLES and TND_SERVERS, are comma-separated; TND_SERVERS is
+// parsed with ParseServers and replaces all servers.
+//
+// Settings are taken from the defaults of NewFileConfig, the config file and
+// the environment variables with increasing precedence, e.g.:
+//
+//	f, err := LoadConfig(path)
+//	...
+//	err = f.LoadEnv()
+//
+// If an environment variable is invalid, a ValidationError with the variable
+// name as field is returned and f is not changed.
+func (f *FileConfig) LoadEnv() error {
+	c := &FileConfig{
+		Config:  f.Config.Copy(),
+		Servers: f.Servers,
+		Dialer:  &net.Dialer{},
+	}
+	if f.Dialer != nil {
+		*c.Dialer = *f.Dialer
+	}
+
+	// apply environment variables
+	set := make(map[string]string)
+	dialer := false
+	for _, v := range envVars {
+		value, ok := os.LookupEnv(v.name)
+		if !ok {
+			continue
+		}
+		if err := v.set(c, value); err != nil {
+			return &ValidationError{Field: v.name, Err: err}
+		}
+		if v.field != "" {
+			set[v.field] = v.name
+		}
+		if strings.HasPrefix(v.name, "TND_DIALER_") {
+			dialer = true
+		}
+	}
+
+	// keep missing dialer settings missing, so the current dialer is kept
+	if f.Dialer == nil && !dialer {
+		c.Dialer = nil
+	}
+
+	// check config, report invalid settings with the variable name
+	if err := c.Config.Validate(); err != nil {
+		var vErr *ValidationError
+		if errors.As(err, &vErr) {
+			// map list elements like "RouteTables[0]" to the list
+			field, _, _ := strings.Cut(vErr.Field, "[")
+			if set[field] != "" {
+				return &ValidationError{Field: set[field], Err: err}
+			}
+		}
+		return err
+	}
+
+	*f = *c
+	return nil
+}
@@ -0,0 +1,169 @@
+package tnd
+
+import (
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// TestFileConfigLoadEnv tests LoadEnv of FileConfig.
+func TestFileConfigLoadEnv(t *testing.T) {
+	hash := testHash("hash1")
+
+	// test without environment variables
+	f := NewFileConfig()
+	if err := f.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(f, NewFileConfig()) {
+		t.Errorf("got %v, want defaults", f)
+	}
+
+	// test all environment variables
+	for name, value := range map[string]string{
+		"TND_WATCH_FILES":             "/test/resolv.conf,/test/other.conf",
+		"TND_WAIT_CHECK":              "2s",
+		"TND_HTTPS_TIMEOUT":           "3s",
+		"TND_UNTRUSTED_TIMER":         "4s",
+		"TND_TRUSTED_TIMER":           "5m",
+		"TND_TRUST_POLICY":            "quorum",
+		"TND_TRUST_QUORUM":            "2",
+		"TND_PARALLEL_PROBES":         "true",
+		"TND_PROBE_TIMEOUT":           "500ms",
+		"TND_DEBOUNCE_QUIET":          "100ms",
+		"TND_DEBOUNCE_MAX_DELAY":      "1s",
+		"TND_ROUTE_TABLES":            "254,255",
+		"TND_ROUTE_PROTOCOLS":         "16",
+		"TND_ROUTE_IGNORE_INTERFACES": "tun0,,tun1",
+		"TND_ROUTE_DEFAULT_ONLY":      "true",
+		"TND_ROUTE_FAMILIES":          "6",
+		"TND_SERVERS":                 "https://test.example.com:" + hash,
+		"TND_DIALER_TIMEOUT":          "1s",
+		"TND_DIALER_KEEP_ALIVE":       "30s",
+		"TND_DIALER_FALLBACK_DELAY":   "300ms",
+		"TND_DIALER_LOCAL_ADDRESS":    "127.0.0.1",
+	} {
+		t.Setenv(name, value)
+	}
+	f = NewFileConfig()
+	if err := f.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+	want := &FileConfig{
+		Config: &Config{
+			WatchFiles:            []string{"/test/resolv.conf", "/test/other.conf"},
+			WaitCheck:             2 * time.Second,
+			HTTPSTimeout:          3 * time.Second,
+			UntrustedTimer:        4 * time.Second,
+			TrustedTimer:          5 * time.Minute,
+			TrustPolicy:           TrustPolicyQuorum,
+			TrustQuorum:           2,
+			ParallelProbes:        true,
+			ProbeTimeout:          500 * time.Millisecond,
+			DebounceQuiet:         100 * time.Millisecond,
+			DebounceMaxDelay:      time.Second,
+			RouteTables:           []int{254, 255},
+			RouteProtocols:        []int{16},
+			RouteIgnoreInterfaces: []string{"tun0", "tun1"},
+			RouteDefaultOnly:      true,
+			RouteFamilies:         []int{6},
+		},
+		Servers: []*Server{
+			{URL: "https://test.example.com", Hashes: []string{hash}},
+		},
+		Dialer: &net.Dialer{
+			Timeout:       time.Second,
+			KeepAlive:     30 * time.Second,
+			FallbackDelay: 300 * time.Millisecond,
+			LocalAddr:     &net.TCPAddr{IP: net.ParseIP("127.0.0.1")},
+		},
+	}
+	if !reflect.DeepEqual(f, want) {
+		t.Errorf("got %v, want %v", f, want)
+	}
+}
+
+// TestFileConfigLoadEnvPrecedence tests the precedence of defaults, config
+// file and environment variables in LoadEnv of FileConfig.
+func TestFileConfigLoadEnvPrecedence(t *testing.T) {
+	f, err := LoadConfig(writeConfigFile(t, `{
+		"wait_check": "2s",
+		"https_timeout": "3s",
+		"dialer": {"timeout": "1s"}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TND_HTTPS_TIMEOUT", "4s")
+	if err := f.LoadEnv(); err != nil {
+		t.Fatal(err)
+	}
+
+	// default, file and environment setting
+	if f.Config.TrustedTimer != TrustedTimer ||
+		f.Config.WaitCheck != 2*time.Second ||
+		f.Config.HTTPSTimeout != 4*time.Second ||
+		f.Dialer.Timeout != time.Second {
+		t.Errorf("invalid precedence: %v, %v", f.Config, f.Dialer)
+	}
+}
+
+// TestFileConfigLoadEnvInvalid tests LoadEnv of FileConfig with invalid
+// environment variables.
+func TestFileConfigLoadEnvInvalid(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		value string
+	}{
+		{"TND_WAIT_CHECK", "1 second"},
+		{"TND_HTTPS_TIMEOUT", "0s"},
+		{"TND_UNTRUSTED_TIMER", "-1s"},
+		{"TND_TRUSTED_TIMER", ""},
+		{"TND_TRUST_POLICY", "some"},
+		{"TND_TRUST_QUORUM", "two"},
+		{"TND_PARALLEL_PROBES", "maybe"},
+		{"TND_PROBE_TIMEOUT", "-1s"},
+		{"TND_DEBOUNCE_QUIET", "-1s"},
+		{"TND_DEBOUNCE_MAX_DELAY", "-1s"},
+		{"TND_ROUTE_TABLES", "main"},
+		{"TND_ROUTE_PROTOCOLS", "16,256"},
+		{"TND_ROUTE_DEFAULT_ONLY", "sometimes"},
+		{"TND_ROUTE_FAMILIES", "4,5"},
+		{"TND_WATCH_FILES", ""},
+		{"TND_SERVERS", "https://test.example.com:invalid"},
+		{"TND_DIALER_TIMEOUT", "1"},
+		{"TND_DIALER_LOCAL_ADDRESS", "invalid"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv(test.name, test.value)
+			f := NewFileConfig()
+			var err *ValidationError
+			if e := f.LoadEnv(); !errors.As(e, &err) || err.Field != test.name {
+				t.Errorf("got %v, want error in %s", e, test.name)
+			}
+			if !reflect.DeepEqual(f, NewFileConfig()) {
+				t.Errorf("config should not be changed: %v", f)
+			}
+		})
+	}
+
+	// test invalid field not set by environment variable
+	t.Run("TrustQuorum", func(t *testing.T) {
+		t.Setenv("TND_TRUST_POLICY", "quorum")
+		var err *ValidationError
+		if e := NewFileConfig().LoadEnv(); !errors.As(e, &err) || err.Field != "TrustQuorum" {
+			t.Errorf("got %v, want error in TrustQuorum", e)
+		}
+	})
+}
+
+// TestNewFileConfig tests NewFileConfig.
+func TestNewFileConfig(t *testing.T) {
+	f := NewFileConfig()
+	if !reflect.DeepEqual(f.Config, NewConfig()) ||
+		len(f.Servers) != 0 || f.Dialer == nil {
+		t.Errorf("invalid file config: %v", f)
+	}
+}
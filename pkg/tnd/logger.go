@@ -0,0 +1,41 @@
+package tnd
+
+import (
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+	"github.com/telekom-mms/tnd/internal/logging"
+)
+
+// Logger is a structured logger for the TND. The args of the methods are
+// alternating field names and values as in log/slog.
+type Logger = logging.Logger
+
+// Field names used by the TND in log messages.
+const (
+	FieldError   = logging.FieldError
+	FieldURL     = logging.FieldURL
+	FieldReason  = logging.FieldReason
+	FieldTrigger = logging.FieldTrigger
+	FieldTrusted = logging.FieldTrusted
+	FieldServer  = logging.FieldServer
+	FieldFile    = logging.FieldFile
+	FieldFolder  = logging.FieldFolder
+	FieldOp      = logging.FieldOp
+	FieldDst     = logging.FieldDst
+	FieldGot     = logging.FieldGot
+	FieldWant    = logging.FieldWant
+	FieldEvents  = logging.FieldEvents
+)
+
+// NewLogrusLogger returns a new Logger that logs with the logrus logger l,
+// e.g., a logrus.Logger or logrus.Entry; nil uses the standard logrus
+// logger, which is the default.
+func NewLogrusLogger(l logrus.FieldLogger) Logger {
+	return logging.NewLogrus(l)
+}
+
+// NewSlogLogger returns a new Logger that logs with the slog.Handler h.
+func NewSlogLogger(h slog.Handler) Logger {
+	return logging.NewSlog(h)
+}
@@ -0,0 +1,28 @@
+package tnd
+
+// Metrics receives metrics of the probes from the Detector, e.g., to export
+// them to Prometheus or OpenTelemetry. The methods are called from the
+// Detector's goroutines, possibly concurrently, and should not block.
+type Metrics interface {
+	// ProbeStarted is called when a probe triggered by trigger is started.
+	ProbeStarted(trigger Trigger)
+
+	// ServerChecked is called with the result of every server check.
+	ServerChecked(r *ServerResult)
+
+	// ProbeFinished is called with the result of every probe that is
+	// not canceled.
+	ProbeFinished(r *Result)
+}
+
+// nopMetrics is the default Metrics that ignores all metrics.
+type nopMetrics struct{}
+
+// ProbeStarted ignores a started probe.
+func (nopMetrics) ProbeStarted(Trigger) {}
+
+// ServerChecked ignores a server check.
+func (nopMetrics) ServerChecked(*ServerResult) {}
+
+// ProbeFinished ignores a probe result.
+func (nopMetrics) ProbeFinished(*Result) {}
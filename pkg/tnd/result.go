@@ -0,0 +1,146 @@
+package tnd
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/telekom-mms/tnd/internal/https"
+)
+
+// Trigger is the source that triggered a probe.
+type Trigger int
+
+// Triggers.
+const (
+	TriggerUnknown Trigger = iota
+	TriggerRoute
+	TriggerFile
+	TriggerTimer
+	TriggerManual
+	TriggerConfig
+)
+
+// String returns trigger as string.
+func (t Trigger) String() string {
+	switch t {
+	case TriggerRoute:
+		return "route"
+	case TriggerFile:
+		return "file"
+	case TriggerTimer:
+		return "timer"
+	case TriggerManual:
+		return "manual"
+	case TriggerConfig:
+		return "config"
+	}
+	return "unknown"
+}
+
+// MarshalText returns trigger as text.
+func (t Trigger) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+// Reason is the reason why a trusted https server is not trusted.
+type Reason = https.Reason
+
+// Reasons.
+const (
+	ReasonNone         = https.ReasonNone
+	ReasonUnknown      = https.ReasonUnknown
+	ReasonDNS          = https.ReasonDNS
+	ReasonRefused      = https.ReasonRefused
+	ReasonTimeout      = https.ReasonTimeout
+	ReasonTLSHandshake = https.ReasonTLSHandshake
+	ReasonNoTLS        = https.ReasonNoTLS
+	ReasonHashMismatch = https.ReasonHashMismatch
+	ReasonVerify       = https.ReasonVerify
+)
+
+// Errors returned in ServerResults.
+var (
+	ErrNoTLS        = https.ErrNoTLS
+	ErrHashMismatch = https.ErrHashMismatch
+	ErrVerify       = https.ErrVerify
+)
+
+// ServerResult is the probe result of a single trusted https server.
+type ServerResult struct {
+	// URL is the url of the server.
+	URL string
+
+	// Trusted indicates whether the server is trusted.
+	Trusted bool
+
+	// Reason is the reason why the server is not trusted. A server that
+	// is reachable but presents an unexpected fingerprint has the reason
+	// ReasonHashMismatch.
+	Reason Reason
+
+	// Error is the error that caused the server to be not trusted.
+	Error error
+
+	// Latency is the duration of the server check.
+	Latency time.Duration
+
+	// Fingerprint is the observed fingerprint of the server's
+	// certificate, if available.
+	Fingerprint string
+}
+
+// MarshalJSON returns the server result as JSON. Error is the error message
+// and Latency is a duration string like "12.5ms".
+func (r *ServerResult) MarshalJSON() ([]byte, error) {
+	e := ""
+	if r.Error != nil {
+		e = r.Error.Error()
+	}
+	return json.Marshal(&struct {
+		URL         string `json:"url"`
+		Trusted     bool   `json:"trusted"`
+		Reason      Reason `json:"reason"`
+		Error       string `json:"error,omitempty"`
+		Latency     string `json:"latency"`
+		Fingerprint string `json:"fingerprint,omitempty"`
+	}{
+		URL:         r.URL,
+		Trusted:     r.Trusted,
+		Reason:      r.Reason,
+		Error:       e,
+		Latency:     r.Latency.String(),
+		Fingerprint: r.Fingerprint,
+	})
+}
+
+// newServerResult returns a new ServerResult from the https result r.
+func newServerResult(r *https.Result) *ServerResult {
+	return &ServerResult{
+		URL:         r.URL,
+		Trusted:     r.Trusted,
+		Reason:      r.Reason,
+		Error:       r.Error,
+		Latency:     r.Latency,
+		Fingerprint: r.Fingerprint,
+	}
+}
+
+// Result is a trusted network detection result. It can be marshaled to JSON.
+type Result struct {
+	// Trusted indicates whether the network is trusted.
+	Trusted bool `json:"trusted"`
+
+	// Server is the url of the first trusted server that matched, if
+	// the network is trusted.
+	Server string `json:"server,omitempty"`
+
+	// Servers are the results of the individual server checks in the
+	// order they were checked.
+	Servers []*ServerResult `json:"servers"`
+
+	// Trigger is the source that triggered the probe.
+	Trigger Trigger `json:"trigger"`
+
+	// Time is the time the result was determined.
+	Time time.Time `json:"time"`
+}
@@ -0,0 +1,70 @@
+package tnd
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// TestTriggerString tests String of Trigger.
+func TestTriggerString(t *testing.T) {
+	for trigger, want := range map[Trigger]string{
+		TriggerUnknown: "unknown",
+		TriggerRoute:   "route",
+		TriggerFile:    "file",
+		TriggerTimer:   "timer",
+		TriggerManual:  "manual",
+		TriggerConfig:  "config",
+		Trigger(1000):  "unknown",
+	} {
+		got := trigger.String()
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+}
+
+// TestTriggerMarshalText tests MarshalText of Trigger.
+func TestTriggerMarshalText(t *testing.T) {
+	b, err := TriggerRoute.MarshalText()
+	if err != nil || string(b) != "route" {
+		t.Errorf("got %s, %v, want route", b, err)
+	}
+}
+
+// TestResultJSON tests JSON marshaling of Result.
+func TestResultJSON(t *testing.T) {
+	r := &Result{
+		Trusted: true,
+		Server:  "https://test1.example.com",
+		Servers: []*ServerResult{
+			{
+				URL:     "https://test2.example.com",
+				Reason:  ReasonHashMismatch,
+				Error:   ErrHashMismatch,
+				Latency: 12 * time.Millisecond,
+			},
+			{
+				URL:         "https://test1.example.com",
+				Trusted:     true,
+				Latency:     1500 * time.Microsecond,
+				Fingerprint: "sha256:abc",
+			},
+		},
+		Trigger: TriggerFile,
+		Time:    time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+	}
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"trusted":true,"server":"https://test1.example.com","servers":[` +
+		`{"url":"https://test2.example.com","trusted":false,"reason":"hash mismatch",` +
+		`"error":"https server hash mismatch","latency":"12ms"},` +
+		`{"url":"https://test1.example.com","trusted":true,"reason":"none",` +
+		`"latency":"1.5ms","fingerprint":"sha256:abc"}],` +
+		`"trigger":"file","time":"2025-01-02T03:04:05Z"}`
+	if string(b) != want {
+		t.Errorf("got %s, want %s", b, want)
+	}
+}
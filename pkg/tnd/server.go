@@ -0,0 +1,169 @@
+package tnd
+
+import (
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/telekom-mms/tnd/internal/https"
+)
+
+// ErrInvalidHash is the error when a server hash is invalid.
+var ErrInvalidHash = https.ErrInvalidHash
+
+// ValidateHash checks whether hash is a valid server hash. A hash is either
+// a hex encoded SHA-256 hash of the server's certificate without prefix, or
+// a hex or base64 encoded hash with an algorithm prefix. Certificate hashes
+// use the prefixes "sha256:", "sha384:" and "sha512:", public key pins of
+// the certificate's SubjectPublicKeyInfo as in HPKP use the prefixes
+// "pin-sha256:", "pin-sha384:" and "pin-sha512:".
+func ValidateHash(hash string) error {
+	_, err := https.ParseFingerprint(hash)
+	return err
+}
+
+// Server is a trusted https server.
+type Server struct {
+	// URL is the url of the server.
+	URL string
+
+	// Hashes are the accepted hashes of the server, e.g., the hashes of
+	// the current and the next certificate of the server. See
+	// ValidateHash for the hash formats.
+	Hashes []string
+
+	// MatchChain enables matching the hashes against all certificates in
+	// the certificate chain presented by the server, or in the verified
+	// chains if CABundle is set, instead of only the server's
+	// certificate. This allows pinning an issuing or root CA. Without
+	// CABundle, the validity periods of the certificates are not checked.
+	MatchChain bool
+
+	// CABundle is an optional PEM encoded CA bundle. If set, the
+	// certificate chain presented by the server must be verified against
+	// the CAs in the bundle.
+	CABundle []byte
+
+	// VerifyHostname requires the server's certificate to be valid for
+	// the hostname in URL.
+	VerifyHostname bool
+}
+
+// Copy returns a copy of Server.
+func (s *Server) Copy() *Server {
+	server := *s
+	server.Hashes = append(s.Hashes[:0:0], s.Hashes...)
+	server.CABundle = append(s.CABundle[:0:0], s.CABundle...)
+
+	return &server
+}
+
+// Validate checks Server and returns a ValidationError for the first invalid
+// field, if any.
+func (s *Server) Validate() error {
+	invalid := func(field string, err error) error {
+		return &ValidationError{Field: field, Err: err}
+	}
+
+	// check url
+	u, err := url.Parse(s.URL)
+	switch {
+	case err != nil:
+		return invalid("URL", err)
+	case u.Scheme != "https":
+		return invalid("URL", fmt.Errorf("scheme of %q is not https", s.URL))
+	case u.Host == "":
+		return invalid("URL", fmt.Errorf("no host in %q", s.URL))
+	}
+
+	// check hashes
+	if len(s.Hashes) == 0 {
+		return invalid("Hashes", errors.New("no hashes"))
+	}
+	for i, hash := range s.Hashes {
+		if err := ValidateHash(hash); err != nil {
+			return invalid(fmt.Sprintf("Hashes[%d]", i), err)
+		}
+	}
+
+	// check ca bundle
+	if len(s.CABundle) > 0 && !x509.NewCertPool().AppendCertsFromPEM(s.CABundle) {
+		return invalid("CABundle", errors.New("no valid PEM certificates"))
+	}
+
+	return nil
+}
+
+// ValidateServers checks servers and returns a ValidationError for the first
+// invalid server field, if any.
+func ValidateServers(servers []*Server) error {
+	for i, s := range servers {
+		if s == nil {
+			return &ValidationError{
+				Field: fmt.Sprintf("Servers[%d]", i),
+				Err:   errors.New("server is nil"),
+			}
+		}
+		var err *ValidationError
+		if errors.As(s.Validate(), &err) {
+			return &ValidationError{
+				Field: fmt.Sprintf("Servers[%d].%s", i, err.Field),
+				Err:   err.Err,
+			}
+		}
+	}
+	return nil
+}
+
+// splitServer splits the https server s into url and hash.
+func splitServer(s string) (url, hash string, ok bool) {
+	i := strings.LastIndex(s, ":")
+	if i == -1 || len(s) < i+2 {
+		return "", "", false
+	}
+	url, hash = s[:i], s[i+1:]
+
+	// handle hashes with algorithm prefix, e.g., url:sha384:hash or
+	// url:pin-sha256:hash; the prefix is neither a port nor part of
+	// the url's host or path
+	if j := strings.LastIndex(url, ":"); j != -1 {
+		prefix := url[j+1:]
+		if !strings.ContainsAny(prefix, "/]") &&
+			strings.Trim(prefix, "0123456789") != "" {
+			url, hash = url[:j], prefix+":"+hash
+		}
+	}
+	return url, hash, true
+}
+
+// ParseServers parses the comma-separated list of trusted https servers in
+// s. Each server is specified as url:hash pair, see ValidateHash for the hash
+// formats. Multiple hashes of the same server, e.g., for certificate
+// rotation, are specified as multiple url:hash pairs with the same url. If a
+// server is invalid, a ValidationError is returned.
+func ParseServers(s string) ([]*Server, error) {
+	servers := []*Server{}
+	urls := make(map[string]*Server)
+	for i, pair := range strings.Split(s, ",") {
+		url, hash, ok := splitServer(pair)
+		if !ok {
+			return nil, &ValidationError{
+				Field: fmt.Sprintf("Servers[%d]", i),
+				Err:   fmt.Errorf("%q is not a url:hash pair", pair),
+			}
+		}
+		if server, ok := urls[url]; ok {
+			server.Hashes = append(server.Hashes, hash)
+			continue
+		}
+		server := &Server{URL: url, Hashes: []string{hash}}
+		urls[url] = server
+		servers = append(servers, server)
+	}
+	if err := ValidateServers(servers); err != nil {
+		return nil, err
+	}
+	return servers, nil
+}
@@ -0,0 +1,164 @@
+package tnd
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestServerCopy tests Copy of Server.
+func TestServerCopy(t *testing.T) {
+	// test copy
+	want := &Server{
+		URL:            "https://test.example.com",
+		Hashes:         []string{"hash1", "hash2"},
+		MatchChain:     true,
+		CABundle:       []byte("test bundle"),
+		VerifyHostname: true,
+	}
+	got := want.Copy()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	// test modification after copy
+	got.Hashes[0] = "something else"
+	got.CABundle[0] = 'x'
+	if reflect.DeepEqual(got.Hashes, want.Hashes) ||
+		reflect.DeepEqual(got.CABundle, want.CABundle) {
+		t.Error("copies should not be equal after modification")
+	}
+}
+
+// TestValidateHash tests ValidateHash.
+func TestValidateHash(t *testing.T) {
+	hash := testHash("test")
+
+	// test valid
+	for _, valid := range []string{
+		hash,
+		"sha256:" + hash,
+		PinPrefix + hash,
+	} {
+		if err := ValidateHash(valid); err != nil {
+			t.Errorf("%s: unexpected error: %v", valid, err)
+		}
+	}
+
+	// test invalid
+	for _, invalid := range []string{
+		"",
+		"invalid",
+		hash[:32],
+		"sha384:" + hash,
+	} {
+		if err := ValidateHash(invalid); !errors.Is(err, ErrInvalidHash) {
+			t.Errorf("%s: validation should fail", invalid)
+		}
+	}
+}
+
+// TestServerValidate tests Validate of Server.
+func TestServerValidate(t *testing.T) {
+	hash := testHash("test")
+
+	// test valid
+	for _, valid := range []*Server{
+		{URL: "https://test.example.com", Hashes: []string{hash}},
+		{URL: "https://127.0.0.1:443/path", Hashes: []string{hash, PinPrefix + hash}},
+		{URL: "https://test.example.com", Hashes: []string{hash}, CABundle: testCABundle()},
+	} {
+		if err := valid.Validate(); err != nil {
+			t.Errorf("%v: unexpected error: %v", valid, err)
+		}
+	}
+
+	// test invalid
+	for field, invalid := range map[string]*Server{
+		"URL":       {URL: "http://test.example.com", Hashes: []string{hash}},
+		"Hashes":    {URL: "https://test.example.com"},
+		"Hashes[1]": {URL: "https://test.example.com", Hashes: []string{hash, hash[:20]}},
+		"CABundle":  {URL: "https://test.example.com", Hashes: []string{hash}, CABundle: []byte("invalid")},
+	} {
+		var err *ValidationError
+		if !errors.As(invalid.Validate(), &err) || err.Field != field {
+			t.Errorf("%v: got %v, want error in %s", invalid, err, field)
+		}
+	}
+	for _, url := range []string{"", "test.example.com", "https://", "https://%zz", "ftp://x"} {
+		var err *ValidationError
+		s := &Server{URL: url, Hashes: []string{hash}}
+		if !errors.As(s.Validate(), &err) || err.Field != "URL" {
+			t.Errorf("%s: got %v, want error in URL", url, err)
+		}
+	}
+}
+
+// TestValidateServers tests ValidateServers.
+func TestValidateServers(t *testing.T) {
+	hash := testHash("test")
+	valid := &Server{URL: "https://test.example.com", Hashes: []string{hash}}
+
+	// test valid
+	if err := ValidateServers(nil); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := ValidateServers([]*Server{valid, valid}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	// test invalid
+	for field, servers := range map[string][]*Server{
+		"Servers[1]":     {valid, nil},
+		"Servers[1].URL": {valid, {URL: "http://test.example.com", Hashes: []string{hash}}},
+	} {
+		var err *ValidationError
+		if !errors.As(ValidateServers(servers), &err) || err.Field != field {
+			t.Errorf("got %v, want error in %s", err, field)
+		}
+	}
+}
+
+// TestParseServers tests ParseServers.
+func TestParseServers(t *testing.T) {
+	hash1 := testHash("hash1")
+	hash2 := testHash("hash2")
+
+	// test valid
+	for s, want := range map[string][]*Server{
+		"https://test.example.com:" + hash1: {
+			{URL: "https://test.example.com", Hashes: []string{hash1}},
+		},
+		"https://test.example.com:443:sha256:" + hash1: {
+			{URL: "https://test.example.com:443", Hashes: []string{"sha256:" + hash1}},
+		},
+		"https://[::1]:443/path:" + PinPrefix + hash1: {
+			{URL: "https://[::1]:443/path", Hashes: []string{PinPrefix + hash1}},
+		},
+		"https://test1.example.com:" + hash1 +
+			",https://test2.example.com:" + hash2 +
+			",https://test1.example.com:" + hash2: {
+			{URL: "https://test1.example.com", Hashes: []string{hash1, hash2}},
+			{URL: "https://test2.example.com", Hashes: []string{hash2}},
+		},
+	} {
+		got, err := ParseServers(s)
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, %v, want %v", s, got, err, want)
+		}
+	}
+
+	// test invalid
+	for s, field := range map[string]string{
+		"":                                 "Servers[0]",
+		"https://test.example.com":         "Servers[0].URL",
+		"https://test.example.com:invalid": "Servers[0].Hashes[0]",
+		"http://test.example.com:" + hash1: "Servers[0].URL",
+		"https://test.example.com:" + hash1 + ",": "Servers[1]",
+	} {
+		var err *ValidationError
+		if _, e := ParseServers(s); !errors.As(e, &err) || err.Field != field {
+			t.Errorf("%s: got %v, want error in %s", s, e, field)
+		}
+	}
+}
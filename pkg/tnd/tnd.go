@@ -2,17 +2,32 @@
 package tnd
 
 import (
+	"context"
 	"net"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TND is the trusted network detection.
 type TND interface {
+	SetConfig(config *Config) error
+	GetConfig() *Config
 	SetServers(map[string]string)
 	GetServers() map[string]string
+	SetTrustedServers(servers []*Server) error
+	GetTrustedServers() []*Server
+	SetFileConfig(f *FileConfig) error
+	SetConfigFile(path string)
 	SetDialer(dialer *net.Dialer)
 	GetDialer() *net.Dialer
+	SetMetrics(metrics Metrics)
+	SetTracerProvider(provider trace.TracerProvider)
+	SetLogger(logger Logger)
 	Start() error
 	Stop()
 	Probe()
+	Check(ctx context.Context) (*Result, error)
 	Results() chan bool
+	DetailedResults() chan *Result
+	Errors() chan error
 }
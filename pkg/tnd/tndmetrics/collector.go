@@ -0,0 +1,215 @@
+// Package tndmetrics contains a metrics collector for the trusted network
+// detection that exports the metrics in the Prometheus text format.
+package tndmetrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// Buckets are the upper bounds in seconds of the server check latency
+// histogram buckets.
+var Buckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// histogram is a latency histogram.
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// observe adds the latency in seconds v to the histogram.
+func (h *histogram) observe(v float64) {
+	for i, b := range Buckets {
+		if v <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// failure is the key of the failure counts.
+type failure struct {
+	url    string
+	reason tnd.Reason
+}
+
+// Collector collects the metrics of a TND. It implements tnd.Metrics and
+// http.Handler, which serves the metrics in the Prometheus text format.
+type Collector struct {
+	mu        sync.Mutex
+	probes    map[tnd.Trigger]uint64
+	latencies map[string]*histogram
+	failures  map[failure]uint64
+	results   uint64
+	trusted   bool
+	changes   uint64
+	changed   time.Time
+
+	// now returns the current time, for testing
+	now func() time.Time
+}
+
+// ProbeStarted counts the probe triggered by trigger.
+func (c *Collector) ProbeStarted(trigger tnd.Trigger) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.probes[trigger]++
+}
+
+// ServerChecked adds the latency and the failure of the server check r.
+func (c *Collector) ServerChecked(r *tnd.ServerResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	h := c.latencies[r.URL]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(Buckets))}
+		c.latencies[r.URL] = h
+	}
+	h.observe(r.Latency.Seconds())
+	if !r.Trusted {
+		c.failures[failure{url: r.URL, reason: r.Reason}]++
+	}
+}
+
+// ProbeFinished sets the trusted state and counts state changes with the
+// probe result r.
+func (c *Collector) ProbeFinished(r *tnd.Result) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results == 0 || r.Trusted != c.trusted {
+		if c.results > 0 {
+			c.changes++
+		}
+		c.changed = r.Time
+		if c.changed.IsZero() {
+			c.changed = c.now()
+		}
+	}
+	c.trusted = r.Trusted
+	c.results++
+}
+
+// label returns the label name and value v in the Prometheus text format.
+func label(name, v string) string {
+	v = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(v)
+	return name + `="` + v + `"`
+}
+
+// float returns v in the Prometheus text format.
+func float(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// sortedKeys returns the keys of m sorted by their string representation.
+func sortedKeys[K comparable, V any](m map[K]V, str func(K) string) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.SortFunc(keys, func(a, b K) int {
+		return strings.Compare(str(a), str(b))
+	})
+	return keys
+}
+
+// WriteTo writes the metrics in the Prometheus text format to w.
+func (c *Collector) WriteTo(w io.Writer) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b := &strings.Builder{}
+	metric := func(name, typ, help string) {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, typ)
+	}
+
+	// probes
+	metric("tnd_probes_total", "counter",
+		"Number of started probes by trigger.")
+	for _, t := range sortedKeys(c.probes, tnd.Trigger.String) {
+		fmt.Fprintf(b, "tnd_probes_total{%s} %d\n",
+			label("trigger", t.String()), c.probes[t])
+	}
+
+	// server check latencies
+	metric("tnd_server_check_duration_seconds", "histogram",
+		"Latency of the trusted server checks.")
+	for _, url := range sortedKeys(c.latencies, func(s string) string { return s }) {
+		h := c.latencies[url]
+		u := label("url", url)
+		for i, bound := range Buckets {
+			fmt.Fprintf(b, "tnd_server_check_duration_seconds_bucket{%s,%s} %d\n",
+				u, label("le", float(bound)), h.counts[i])
+		}
+		fmt.Fprintf(b, "tnd_server_check_duration_seconds_bucket{%s,%s} %d\n",
+			u, label("le", "+Inf"), h.count)
+		fmt.Fprintf(b, "tnd_server_check_duration_seconds_sum{%s} %s\n",
+			u, float(h.sum))
+		fmt.Fprintf(b, "tnd_server_check_duration_seconds_count{%s} %d\n",
+			u, h.count)
+	}
+
+	// server check failures
+	metric("tnd_server_check_failures_total", "counter",
+		"Number of failed trusted server checks by reason.")
+	for _, f := range sortedKeys(c.failures, func(f failure) string {
+		return f.url + " " + f.reason.String()
+	}) {
+		fmt.Fprintf(b, "tnd_server_check_failures_total{%s,%s} %d\n",
+			label("url", f.url), label("reason", f.reason.String()),
+			c.failures[f])
+	}
+
+	// trusted state
+	if c.results > 0 {
+		trusted := 0
+		if c.trusted {
+			trusted = 1
+		}
+		metric("tnd_trusted", "gauge",
+			"Whether the network is trusted.")
+		fmt.Fprintf(b, "tnd_trusted %d\n", trusted)
+		metric("tnd_state_changes_total", "counter",
+			"Number of changes between trusted and untrusted network.")
+		fmt.Fprintf(b, "tnd_state_changes_total %d\n", c.changes)
+		metric("tnd_state_change_timestamp_seconds", "gauge",
+			"Time of the last trusted network state change.")
+		fmt.Fprintf(b, "tnd_state_change_timestamp_seconds %s\n",
+			float(float64(c.changed.UnixMilli())/1000))
+		metric("tnd_seconds_since_state_change", "gauge",
+			"Seconds since the last trusted network state change.")
+		fmt.Fprintf(b, "tnd_seconds_since_state_change %s\n",
+			float(c.now().Sub(c.changed).Seconds()))
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// ServeHTTP serves the metrics in the Prometheus text format.
+func (c *Collector) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = c.WriteTo(w)
+}
+
+// NewCollector returns a new Collector.
+func NewCollector() *Collector {
+	return &Collector{
+		probes:    make(map[tnd.Trigger]uint64),
+		latencies: make(map[string]*histogram),
+		failures:  make(map[failure]uint64),
+		now:       time.Now,
+	}
+}
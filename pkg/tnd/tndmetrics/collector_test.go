@@ -0,0 +1,108 @@
+package tndmetrics
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/telekom-mms/tnd/pkg/tnd"
+)
+
+// TestCollectorWriteTo tests WriteTo of Collector.
+func TestCollectorWriteTo(t *testing.T) {
+	var _ tnd.Metrics = NewCollector()
+
+	// test without metrics
+	c := NewCollector()
+	b := &bytes.Buffer{}
+	if _, err := c.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(b.String(), "tnd_trusted") ||
+		!strings.Contains(b.String(), "# TYPE tnd_probes_total counter\n") {
+		t.Errorf("invalid metrics without results:\n%s", b)
+	}
+
+	// test with metrics
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c.now = func() time.Time { return start.Add(90 * time.Second) }
+	c.ProbeStarted(tnd.TriggerRoute)
+	c.ProbeStarted(tnd.TriggerTimer)
+	c.ProbeStarted(tnd.TriggerTimer)
+	c.ServerChecked(&tnd.ServerResult{
+		URL:     "https://test1.example.com",
+		Trusted: true,
+		Latency: 20 * time.Millisecond,
+	})
+	c.ServerChecked(&tnd.ServerResult{
+		URL:     `https://test2.example.com/"quoted"`,
+		Reason:  tnd.ReasonTimeout,
+		Latency: 5 * time.Second,
+	})
+	c.ProbeFinished(&tnd.Result{Trusted: false, Time: start})
+	c.ProbeFinished(&tnd.Result{Trusted: true, Time: start.Add(time.Minute)})
+	c.ProbeFinished(&tnd.Result{Trusted: true, Time: start.Add(2 * time.Minute)})
+
+	b.Reset()
+	if _, err := c.WriteTo(b); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`tnd_probes_total{trigger="route"} 1`,
+		`tnd_probes_total{trigger="timer"} 2`,
+		`tnd_server_check_duration_seconds_bucket{url="https://test1.example.com",le="0.01"} 0`,
+		`tnd_server_check_duration_seconds_bucket{url="https://test1.example.com",le="0.025"} 1`,
+		`tnd_server_check_duration_seconds_bucket{url="https://test1.example.com",le="+Inf"} 1`,
+		`tnd_server_check_duration_seconds_sum{url="https://test1.example.com"} 0.02`,
+		`tnd_server_check_duration_seconds_count{url="https://test1.example.com"} 1`,
+		`tnd_server_check_duration_seconds_bucket{url="https://test2.example.com/\"quoted\"",le="2.5"} 0`,
+		`tnd_server_check_duration_seconds_bucket{url="https://test2.example.com/\"quoted\"",le="5"} 1`,
+		`tnd_server_check_failures_total{url="https://test2.example.com/\"quoted\"",reason="timeout"} 1`,
+		"# TYPE tnd_trusted gauge\ntnd_trusted 1",
+		"tnd_state_changes_total 1",
+		"tnd_state_change_timestamp_seconds 1.70406726e+09",
+		"tnd_seconds_since_state_change 30",
+	} {
+		if !strings.Contains(b.String(), want+"\n") {
+			t.Errorf("missing %s in:\n%s", want, b)
+		}
+	}
+	if strings.Contains(b.String(), `reason="none"`) {
+		t.Errorf("trusted server should not be counted as failure:\n%s", b)
+	}
+}
+
+// TestCollectorProbeFinished tests ProbeFinished of Collector.
+func TestCollectorProbeFinished(t *testing.T) {
+	now := time.Now()
+	c := NewCollector()
+	c.now = func() time.Time { return now }
+
+	// test result without time
+	c.ProbeFinished(&tnd.Result{Trusted: true})
+	if !c.trusted || c.changes != 0 || !c.changed.Equal(now) {
+		t.Errorf("invalid state: %t %d %v", c.trusted, c.changes, c.changed)
+	}
+
+	// test state changes
+	c.ProbeFinished(&tnd.Result{Trusted: false})
+	c.ProbeFinished(&tnd.Result{Trusted: true})
+	if !c.trusted || c.changes != 2 {
+		t.Errorf("invalid state: %t %d", c.trusted, c.changes)
+	}
+}
+
+// TestCollectorServeHTTP tests ServeHTTP of Collector.
+func TestCollectorServeHTTP(t *testing.T) {
+	c := NewCollector()
+	c.ProbeStarted(tnd.TriggerManual)
+
+	rec := httptest.NewRecorder()
+	c.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	if !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/plain") ||
+		!strings.Contains(rec.Body.String(), `tnd_probes_total{trigger="manual"} 1`) {
+		t.Errorf("invalid response: %v\n%s", rec.Header(), rec.Body)
+	}
+}
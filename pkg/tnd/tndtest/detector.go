@@ -2,7 +2,11 @@
 package tndtest
 
 import (
+	"context"
 	"net"
+
+	"github.com/telekom-mms/tnd/pkg/tnd"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Funcs are functions used by Detector for use in tests.
@@ -15,6 +19,19 @@ type Funcs struct {
 	Stop       func()
 	Probe      func()
 	Results    func() chan bool
+
+	DetailedResults   func() chan *tnd.Result
+	SetTrustedServers func(servers []*tnd.Server) error
+	GetTrustedServers func() []*tnd.Server
+	SetConfig         func(config *tnd.Config) error
+	GetConfig         func() *tnd.Config
+	SetFileConfig     func(f *tnd.FileConfig) error
+	SetConfigFile     func(path string)
+	Errors            func() chan error
+	Check             func(ctx context.Context) (*tnd.Result, error)
+	SetMetrics        func(metrics tnd.Metrics)
+	SetTracerProvider func(provider trace.TracerProvider)
+	SetLogger         func(logger tnd.Logger)
 }
 
 // Detector is a simple Detector for use in tests.
@@ -38,6 +55,74 @@ func (d *Detector) GetServers() map[string]string {
 	return nil
 }
 
+// SetTrustedServers sets the trusted https servers.
+func (d *Detector) SetTrustedServers(servers []*tnd.Server) error {
+	if d.Funcs.SetTrustedServers != nil {
+		return d.Funcs.SetTrustedServers(servers)
+	}
+	return nil
+}
+
+// GetTrustedServers returns the trusted https servers.
+func (d *Detector) GetTrustedServers() []*tnd.Server {
+	if d.Funcs.GetTrustedServers != nil {
+		return d.Funcs.GetTrustedServers()
+	}
+	return nil
+}
+
+// SetConfig sets the Config.
+func (d *Detector) SetConfig(config *tnd.Config) error {
+	if d.Funcs.SetConfig != nil {
+		return d.Funcs.SetConfig(config)
+	}
+	return nil
+}
+
+// GetConfig returns the Config.
+func (d *Detector) GetConfig() *tnd.Config {
+	if d.Funcs.GetConfig != nil {
+		return d.Funcs.GetConfig()
+	}
+	return nil
+}
+
+// SetFileConfig sets the Config, trusted servers and dialer.
+func (d *Detector) SetFileConfig(f *tnd.FileConfig) error {
+	if d.Funcs.SetFileConfig != nil {
+		return d.Funcs.SetFileConfig(f)
+	}
+	return nil
+}
+
+// SetConfigFile sets the config file.
+func (d *Detector) SetConfigFile(path string) {
+	if d.Funcs.SetConfigFile != nil {
+		d.Funcs.SetConfigFile(path)
+	}
+}
+
+// SetMetrics sets the Metrics.
+func (d *Detector) SetMetrics(metrics tnd.Metrics) {
+	if d.Funcs.SetMetrics != nil {
+		d.Funcs.SetMetrics(metrics)
+	}
+}
+
+// SetTracerProvider sets the OpenTelemetry TracerProvider.
+func (d *Detector) SetTracerProvider(provider trace.TracerProvider) {
+	if d.Funcs.SetTracerProvider != nil {
+		d.Funcs.SetTracerProvider(provider)
+	}
+}
+
+// SetLogger sets the Logger.
+func (d *Detector) SetLogger(logger tnd.Logger) {
+	if d.Funcs.SetLogger != nil {
+		d.Funcs.SetLogger(logger)
+	}
+}
+
 // SetDialer sets a custom dialer for the https connections.
 func (d *Detector) SetDialer(dialer *net.Dialer) {
 	if d.Funcs.SetDialer != nil {
@@ -75,6 +160,14 @@ func (d *Detector) Probe() {
 	}
 }
 
+// Check runs a single probe and returns its result.
+func (d *Detector) Check(ctx context.Context) (*tnd.Result, error) {
+	if d.Funcs.Check != nil {
+		return d.Funcs.Check(ctx)
+	}
+	return nil, nil
+}
+
 // Results returns the results channel.
 func (d *Detector) Results() chan bool {
 	if d.Funcs.Results != nil {
@@ -83,6 +176,22 @@ func (d *Detector) Results() chan bool {
 	return nil
 }
 
+// DetailedResults returns the detailed results channel.
+func (d *Detector) DetailedResults() chan *tnd.Result {
+	if d.Funcs.DetailedResults != nil {
+		return d.Funcs.DetailedResults()
+	}
+	return nil
+}
+
+// Errors returns the errors channel.
+func (d *Detector) Errors() chan error {
+	if d.Funcs.Errors != nil {
+		return d.Funcs.Errors()
+	}
+	return nil
+}
+
 // NewDetector returns a new Detector.
 func NewDetector() *Detector {
 	return &Detector{}
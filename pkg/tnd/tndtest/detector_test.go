@@ -1,9 +1,13 @@
 package tndtest
 
 import (
+	"context"
 	"net"
 	"reflect"
 	"testing"
+
+	"github.com/telekom-mms/tnd/pkg/tnd"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // TestDetectorSetGetServers tests SetServers and GetServers of Detector.
@@ -34,6 +38,41 @@ func TestDetectorSetGetServers(t *testing.T) {
 	}
 }
 
+// TestDetectorSetGetTrustedServers tests SetTrustedServers and
+// GetTrustedServers of Detector.
+func TestDetectorSetGetTrustedServers(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	servers := []*tnd.Server{{
+		URL:    "https://example.com",
+		Hashes: []string{"abcdefabcdefabcdefabcdef"},
+	}}
+	if err := d.SetTrustedServers(servers); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if d.GetTrustedServers() != nil {
+		t.Errorf("servers should be nil")
+	}
+
+	// test func set
+	testServers := []*tnd.Server{}
+	d.Funcs.SetTrustedServers = func(s []*tnd.Server) error {
+		testServers = s
+		return nil
+	}
+	d.Funcs.GetTrustedServers = func() []*tnd.Server {
+		return testServers
+	}
+	if err := d.SetTrustedServers(servers); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	got := d.GetTrustedServers()
+	if !reflect.DeepEqual(got, servers) {
+		t.Errorf("got %v, want %v", got, servers)
+	}
+}
+
 // TestDetectorSetDialer tests SetDialer and GetDialer of Detector.
 func TestDetectorSetGetDialer(t *testing.T) {
 	d := NewDetector()
@@ -61,6 +100,38 @@ func TestDetectorSetGetDialer(t *testing.T) {
 	}
 }
 
+// TestDetectorSetGetConfig tests SetConfig and GetConfig of Detector.
+func TestDetectorSetGetConfig(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	if err := d.SetConfig(tnd.NewConfig()); err != nil {
+		t.Fatal(err)
+	}
+	if d.GetConfig() != nil {
+		t.Errorf("config should be nil")
+	}
+
+	// test func set
+	want := tnd.NewConfig()
+	var testConfig *tnd.Config
+	d.Funcs.SetConfig = func(config *tnd.Config) error {
+		testConfig = config
+		return nil
+	}
+	d.Funcs.GetConfig = func() *tnd.Config {
+		return testConfig
+	}
+
+	if err := d.SetConfig(want); err != nil {
+		t.Fatal(err)
+	}
+	got := d.GetConfig()
+	if got != want {
+		t.Errorf("got %p, want %p", got, want)
+	}
+}
+
 // TestDetectorStart tests Start of Detector.
 func TestDetectorStart(t *testing.T) {
 	d := NewDetector()
@@ -145,6 +216,163 @@ func TestDetectorResults(t *testing.T) {
 	}
 }
 
+// TestDetectorDetailedResults tests DetailedResults of Detector.
+func TestDetectorDetailedResults(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	if d.DetailedResults() != nil {
+		t.Errorf("got unexpected detailed results channel")
+	}
+
+	// test func set
+	want := make(chan *tnd.Result)
+	d.Funcs.DetailedResults = func() chan *tnd.Result {
+		return want
+	}
+	got := d.DetailedResults()
+	if got != want {
+		t.Errorf("got %p, want %p", got, want)
+	}
+}
+
+// TestDetectorCheck tests Check of Detector.
+func TestDetectorCheck(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	if r, err := d.Check(context.Background()); r != nil || err != nil {
+		t.Errorf("got %v, %v, want nil", r, err)
+	}
+
+	// test func set
+	want := &tnd.Result{Trusted: true}
+	d.Funcs.Check = func(context.Context) (*tnd.Result, error) {
+		return want, nil
+	}
+	got, err := d.Check(context.Background())
+	if got != want || err != nil {
+		t.Errorf("got %v, %v, want %v", got, err, want)
+	}
+}
+
+// TestDetectorSetFileConfig tests SetFileConfig of Detector.
+func TestDetectorSetFileConfig(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	if err := d.SetFileConfig(tnd.NewFileConfig()); err != nil {
+		t.Fatal(err)
+	}
+
+	// test func set
+	want := tnd.NewFileConfig()
+	var got *tnd.FileConfig
+	d.Funcs.SetFileConfig = func(f *tnd.FileConfig) error {
+		got = f
+		return nil
+	}
+	if err := d.SetFileConfig(want); err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got %p, want %p", got, want)
+	}
+}
+
+// TestDetectorSetConfigFile tests SetConfigFile of Detector.
+func TestDetectorSetConfigFile(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	d.SetConfigFile("/test/tnd.json")
+
+	// test func set
+	want := "/test/tnd.json"
+	got := ""
+	d.Funcs.SetConfigFile = func(path string) {
+		got = path
+	}
+	d.SetConfigFile(want)
+	if got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+// TestDetectorSetMetrics tests SetMetrics of Detector.
+func TestDetectorSetMetrics(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	d.SetMetrics(nil)
+
+	// test func set
+	called := false
+	d.Funcs.SetMetrics = func(tnd.Metrics) {
+		called = true
+	}
+	d.SetMetrics(nil)
+	if !called {
+		t.Error("SetMetrics func not called")
+	}
+}
+
+// TestDetectorSetTracerProvider tests SetTracerProvider of Detector.
+func TestDetectorSetTracerProvider(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	d.SetTracerProvider(nil)
+
+	// test func set
+	called := false
+	d.Funcs.SetTracerProvider = func(trace.TracerProvider) {
+		called = true
+	}
+	d.SetTracerProvider(nil)
+	if !called {
+		t.Error("SetTracerProvider func not called")
+	}
+}
+
+// TestDetectorSetLogger tests SetLogger of Detector.
+func TestDetectorSetLogger(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	d.SetLogger(nil)
+
+	// test func set
+	called := false
+	d.Funcs.SetLogger = func(tnd.Logger) {
+		called = true
+	}
+	d.SetLogger(nil)
+	if !called {
+		t.Error("SetLogger func not called")
+	}
+}
+
+// TestDetectorErrors tests Errors of Detector.
+func TestDetectorErrors(t *testing.T) {
+	d := NewDetector()
+
+	// test no func set
+	if d.Errors() != nil {
+		t.Errorf("got unexpected errors channel")
+	}
+
+	// test func set
+	want := make(chan error)
+	d.Funcs.Errors = func() chan error {
+		return want
+	}
+	got := d.Errors()
+	if got != want {
+		t.Errorf("got %p, want %p", got, want)
+	}
+}
+
 // TestNewDetector tests NewDetector.
 func TestNewDetector(t *testing.T) {
 	if NewDetector() == nil {
@@ -0,0 +1,163 @@
+package tnd
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+
+	"github.com/telekom-mms/tnd/internal/https"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// TracerName is the name of the OpenTelemetry tracer of the TND.
+const TracerName = "github.com/telekom-mms/tnd/pkg/tnd"
+
+// Span names.
+const (
+	SpanProbe       = "tnd.probe"
+	SpanWait        = "tnd.wait"
+	SpanServerCheck = "tnd.server_check"
+)
+
+// newTracer returns the tracer of the TND from provider; nil disables
+// tracing.
+func newTracer(provider trace.TracerProvider) trace.Tracer {
+	if provider == nil {
+		provider = noop.NewTracerProvider()
+	}
+	return provider.Tracer(TracerName)
+}
+
+// addError records err on span and sets the error status.
+func addError(span trace.Span, err error) {
+	if err == nil {
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// errorAttrs returns the error attribute for err, if any.
+func errorAttrs(err error, attrs ...attribute.KeyValue) []attribute.KeyValue {
+	if err != nil {
+		attrs = append(attrs, attribute.String("error", err.Error()))
+	}
+	return attrs
+}
+
+// clientTrace returns a httptrace.ClientTrace that adds the connection
+// events of a server check to span.
+func clientTrace(span trace.Span) *httptrace.ClientTrace {
+	event := func(name string, attrs ...attribute.KeyValue) {
+		span.AddEvent(name, trace.WithAttributes(attrs...))
+	}
+	return &httptrace.ClientTrace{
+		DNSStart: func(info httptrace.DNSStartInfo) {
+			event("dns start", attribute.String("host", info.Host))
+		},
+		DNSDone: func(info httptrace.DNSDoneInfo) {
+			addrs := []string{}
+			for _, a := range info.Addrs {
+				addrs = append(addrs, a.String())
+			}
+			event("dns resolved", errorAttrs(info.Err,
+				attribute.StringSlice("addrs", addrs))...)
+		},
+		ConnectStart: func(network, addr string) {
+			event("connect start",
+				attribute.String("network", network),
+				attribute.String("addr", addr))
+		},
+		ConnectDone: func(network, addr string, err error) {
+			event("connected", errorAttrs(err,
+				attribute.String("network", network),
+				attribute.String("addr", addr))...)
+		},
+		TLSHandshakeStart: func() {
+			event("tls start")
+		},
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			event("tls done", errorAttrs(err,
+				attribute.String("tls.version", tls.VersionName(state.Version)),
+				attribute.String("tls.cipher", tls.CipherSuiteName(state.CipherSuite)))...)
+		},
+		WroteRequest: func(info httptrace.WroteRequestInfo) {
+			event("request sent", errorAttrs(info.Err)...)
+		},
+		GotFirstResponseByte: func() {
+			event("response received")
+		},
+	}
+}
+
+// startProbeSpan starts the span of the probe with result.
+func (p *prober) startProbeSpan(ctx context.Context, result *Result) (context.Context, trace.Span) {
+	return p.tracer.Start(ctx, SpanProbe, trace.WithAttributes(
+		attribute.String("tnd.trigger", result.Trigger.String()),
+		attribute.String("tnd.trust_policy", p.config.TrustPolicy.String()),
+		attribute.Bool("tnd.parallel_probes", p.config.ParallelProbes),
+		attribute.Int("tnd.servers", len(p.servers)),
+	))
+}
+
+// endProbeSpan ends the span of the probe with result. If ctx is done, the
+// probe is marked as canceled.
+func endProbeSpan(ctx context.Context, span trace.Span, result *Result) {
+	span.SetAttributes(
+		attribute.Bool("tnd.trusted", result.Trusted),
+		attribute.String("tnd.server", result.Server),
+		attribute.Int("tnd.servers_checked", len(result.Servers)),
+		attribute.Bool("tnd.canceled", ctx.Err() != nil),
+	)
+	addError(span, ctx.Err())
+	span.End()
+}
+
+// wait waits the WaitCheck duration in a span or until ctx is done and
+// returns whether the full duration elapsed.
+func (p *prober) wait(ctx context.Context, trigger Trigger) bool {
+	_, span := p.tracer.Start(ctx, SpanWait, trace.WithAttributes(
+		attribute.String("tnd.trigger", trigger.String()),
+		attribute.Int64("tnd.wait_ms", p.config.WaitCheck.Milliseconds()),
+	))
+	defer span.End()
+
+	if !sleep(ctx, p.config.WaitCheck) {
+		addError(span, ctx.Err())
+		return false
+	}
+	return true
+}
+
+// check checks the server s in a span with the connection events of the
+// check, e.g., DNS resolved, connected and TLS handshake done.
+func (p *prober) check(ctx context.Context, trigger Trigger, s *https.Server) *https.Result {
+	ctx, span := p.tracer.Start(ctx, SpanServerCheck, trace.WithAttributes(
+		attribute.String("tnd.trigger", trigger.String()),
+		attribute.String("tnd.server.url", s.URL),
+	))
+	if span.IsRecording() {
+		ctx = httptrace.WithClientTrace(ctx, clientTrace(span))
+	}
+
+	r := s.Check(ctx, p.dialer, p.config.HTTPSTimeout, p.logger)
+	endServerSpan(span, r)
+	return r
+}
+
+// endServerSpan ends the span of the server check with result r.
+func endServerSpan(span trace.Span, r *https.Result) {
+	span.SetAttributes(
+		attribute.Bool("tnd.server.trusted", r.Trusted),
+		attribute.String("tnd.server.reason", r.Reason.String()),
+		attribute.Int64("tnd.server.latency_ms", r.Latency.Milliseconds()),
+	)
+	if r.Fingerprint != "" {
+		span.SetAttributes(attribute.String("tnd.server.fingerprint", r.Fingerprint))
+	}
+	addError(span, r.Error)
+	span.End()
+}
@@ -0,0 +1,180 @@
+package tnd
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// spanAttr returns the value of the attribute key of span.
+func spanAttr(span sdktrace.ReadOnlySpan, key string) attribute.Value {
+	for _, a := range span.Attributes() {
+		if string(a.Key) == key {
+			return a.Value
+		}
+	}
+	return attribute.Value{}
+}
+
+// spanEvents returns the event names of span.
+func spanEvents(span sdktrace.ReadOnlySpan) []string {
+	names := []string{}
+	for _, e := range span.Events() {
+		names = append(names, e.Name)
+	}
+	return names
+}
+
+// TestDetectorSetTracerProvider tests SetTracerProvider of Detector.
+func TestDetectorSetTracerProvider(t *testing.T) {
+	// start test https server
+	ts := httptest.NewTLSServer(http.HandlerFunc(
+		func(http.ResponseWriter, *http.Request) {}))
+	defer ts.Close()
+
+	sha := sha256.Sum256(ts.Certificate().Raw)
+	hash := hex.EncodeToString(sha[:])
+
+	// create detector with tracer provider
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	defer func() { _ = provider.Shutdown(context.Background()) }()
+
+	c := NewConfig()
+	c.WaitCheck = 0
+	tnd := NewDetector(c)
+	tnd.SetTracerProvider(provider)
+
+	// test trusted probe
+	tnd.SetServers(map[string]string{ts.URL: hash})
+	if _, err := tnd.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans := recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	wait, check, probe := spans[0], spans[1], spans[2]
+	if wait.Name() != SpanWait || check.Name() != SpanServerCheck ||
+		probe.Name() != SpanProbe {
+		t.Fatalf("invalid spans: %s, %s, %s", wait.Name(), check.Name(), probe.Name())
+	}
+	for _, span := range []sdktrace.ReadOnlySpan{wait, check} {
+		if span.Parent().SpanID() != probe.SpanContext().SpanID() {
+			t.Errorf("%s should be child of probe span", span.Name())
+		}
+	}
+	for _, span := range spans {
+		if spanAttr(span, "tnd.trigger").AsString() != "manual" {
+			t.Errorf("%s: invalid trigger", span.Name())
+		}
+	}
+	if !spanAttr(probe, "tnd.trusted").AsBool() ||
+		spanAttr(probe, "tnd.server").AsString() != ts.URL ||
+		spanAttr(probe, "tnd.canceled").AsBool() {
+		t.Errorf("invalid probe span attributes: %v", probe.Attributes())
+	}
+	if !spanAttr(check, "tnd.server.trusted").AsBool() ||
+		spanAttr(check, "tnd.server.url").AsString() != ts.URL ||
+		spanAttr(check, "tnd.server.fingerprint").AsString() != hash {
+		t.Errorf("invalid check span attributes: %v", check.Attributes())
+	}
+	events := spanEvents(check)
+	for _, want := range []string{"connected", "tls done", "response received"} {
+		if !slices.Contains(events, want) {
+			t.Errorf("missing event %s in %v", want, events)
+		}
+	}
+
+	// test untrusted probe
+	recorder = tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+	tnd.SetServers(map[string]string{"https://127.0.0.1:1": hash})
+	if _, err := tnd.Check(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	spans = recorder.Ended()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3", len(spans))
+	}
+	check, probe = spans[1], spans[2]
+	if check.Status().Code != codes.Error ||
+		spanAttr(check, "tnd.server.reason").AsString() != "refused" ||
+		spanAttr(probe, "tnd.trusted").AsBool() {
+		t.Errorf("invalid untrusted spans: %v, %v", check.Attributes(), probe.Attributes())
+	}
+
+	// test canceled probe
+	recorder = tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = tnd.Check(ctx)
+	spans = recorder.Ended()
+	probe = spans[len(spans)-1]
+	if !spanAttr(probe, "tnd.canceled").AsBool() || probe.Status().Code != codes.Error {
+		t.Errorf("invalid canceled probe span: %v", probe.Attributes())
+	}
+
+	// test disabled tracing
+	recorder = tracetest.NewSpanRecorder()
+	provider.RegisterSpanProcessor(recorder)
+	tnd.SetTracerProvider(nil)
+	_, _ = tnd.Check(context.Background())
+	if len(recorder.Ended()) != 0 {
+		t.Error("tracing should be disabled")
+	}
+}
+
+// TestDetectorProbeDebounceWait tests the WaitCheck of sequential probes of
+// Detector with and without debouncing.
+func TestDetectorProbeDebounceWait(t *testing.T) {
+	for _, test := range []struct {
+		quiet time.Duration
+		waits int
+	}{
+		{0, 3},
+		{100 * time.Millisecond, 1},
+	} {
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+		// create detector with three untrusted servers, all are checked
+		c := NewConfig()
+		c.WaitCheck = 0
+		c.DebounceQuiet = test.quiet
+		tnd := NewDetector(c)
+		tnd.SetTracerProvider(provider)
+		tnd.SetServers(map[string]string{
+			"https://127.0.0.1:1/1": testHash("invalid"),
+			"https://127.0.0.1:1/2": testHash("invalid"),
+			"https://127.0.0.1:1/3": testHash("invalid"),
+		})
+		r, err := tnd.Check(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		waits := 0
+		for _, span := range recorder.Ended() {
+			if span.Name() == SpanWait {
+				waits++
+			}
+		}
+		if len(r.Servers) != 3 || waits != test.waits {
+			t.Errorf("%v: got %d checks and %d waits, want 3 and %d",
+				test.quiet, len(r.Servers), waits, test.waits)
+		}
+		_ = provider.Shutdown(context.Background())
+	}
+}